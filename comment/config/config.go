@@ -0,0 +1,126 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var (
+	// initOnce protects the following
+	initOnce sync.Once
+	cfg      *Config
+)
+
+// Config holds the settings of the comment service.
+type Config struct {
+	// Retention is how long soft-deleted comments are kept before they are purged.
+	Retention time.Duration
+	// PurgeInterval is how often the background purger runs.
+	PurgeInterval time.Duration
+	// PurgeBatchSize bounds the number of comments purged by a single query.
+	PurgeBatchSize int
+	// AdminToken guards the admin endpoints, which are disabled when it is empty.
+	AdminToken string
+
+	// RateLimitWindow is the period the post rate limits refer to, both are disabled when it is zero.
+	RateLimitWindow time.Duration
+	// AuthorRateLimit is how many comments an author may post per window across all orgs,
+	// the limit is disabled when it is zero.
+	AuthorRateLimit int
+	// OrgRateLimit is how many comments may be posted to an org per window, the limit is disabled when it is zero.
+	OrgRateLimit int
+	// SlowModeInterval is the minimum time between two comments of an author to the same org,
+	// slow mode is disabled when it is zero.
+	SlowModeInterval time.Duration
+
+	// IdempotencyKeyTTL is how long the response to a request with an Idempotency-Key is replayed.
+	IdempotencyKeyTTL time.Duration
+
+	// LoginCacheTTL is how long the Github login of a bearer token is cached, the cache is disabled when it is zero.
+	LoginCacheTTL time.Duration
+
+	// OrgDefaults are the settings of orgs missing in Orgs, see ForOrg.
+	OrgDefaults OrgSettings
+	// Orgs holds the settings of individual orgs keyed by lower case org name.
+	Orgs map[string]OrgSettings
+}
+
+// Get reads the config from the environment once and returns it.
+func Get() *Config {
+	initOnce.Do(func() {
+		cfg = load()
+	})
+	return cfg
+}
+
+// load reads the config from the environment, falling back to defaults for unset or invalid values.
+func load() *Config {
+	orgDefaults, orgs := loadOrgSettings(os.Getenv("ORG_SETTINGS_FILE"))
+	return &Config{
+		Retention:      durationEnv("COMMENT_RETENTION", 30*24*time.Hour),
+		PurgeInterval:  durationEnv("PURGE_INTERVAL", time.Hour),
+		PurgeBatchSize: intEnv("PURGE_BATCH_SIZE", 500),
+		AdminToken:     os.Getenv("ADMIN_TOKEN"),
+
+		RateLimitWindow:  optionalDurationEnv("RATE_LIMIT_WINDOW", time.Minute),
+		AuthorRateLimit:  optionalIntEnv("AUTHOR_RATE_LIMIT", 10),
+		OrgRateLimit:     optionalIntEnv("ORG_RATE_LIMIT", 100),
+		SlowModeInterval: optionalDurationEnv("SLOW_MODE_INTERVAL", 0),
+
+		IdempotencyKeyTTL: durationEnv("IDEMPOTENCY_KEY_TTL", 24*time.Hour),
+
+		LoginCacheTTL: optionalDurationEnv("LOGIN_CACHE_TTL", time.Minute),
+
+		OrgDefaults: orgDefaults,
+		Orgs:        orgs,
+	}
+}
+
+// durationEnv parses a positive duration like "720h" from the environment.
+func durationEnv(key string, def time.Duration) time.Duration {
+	return parseDurationEnv(key, def, false)
+}
+
+// optionalDurationEnv parses a positive duration, or zero which disables the setting, from the environment.
+func optionalDurationEnv(key string, def time.Duration) time.Duration {
+	return parseDurationEnv(key, def, true)
+}
+
+func parseDurationEnv(key string, def time.Duration, allowZero bool) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 || (d == 0 && !allowZero) {
+		log.Printf("ERROR: invalid $%s %q, using default %v", key, v, def)
+		return def
+	}
+	return d
+}
+
+// intEnv parses a positive integer from the environment.
+func intEnv(key string, def int) int {
+	return parseIntEnv(key, def, false)
+}
+
+// optionalIntEnv parses a positive integer, or zero which disables the setting, from the environment.
+func optionalIntEnv(key string, def int) int {
+	return parseIntEnv(key, def, true)
+}
+
+func parseIntEnv(key string, def int, allowZero bool) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 || (n == 0 && !allowZero) {
+		log.Printf("ERROR: invalid $%s %q, using default %v", key, v, def)
+		return def
+	}
+	return n
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGet(t *testing.T) {
+	c1 := Get()
+	c2 := Get()
+	assert.Equal(t, c1, c2)
+}
+
+func TestLoad(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		c := load()
+		assert.Equal(t, 30*24*time.Hour, c.Retention)
+		assert.Equal(t, time.Hour, c.PurgeInterval)
+		assert.Equal(t, 500, c.PurgeBatchSize)
+		assert.Equal(t, "", c.AdminToken)
+		assert.Equal(t, time.Minute, c.RateLimitWindow)
+		assert.Equal(t, 10, c.AuthorRateLimit)
+		assert.Equal(t, 100, c.OrgRateLimit)
+		assert.Equal(t, time.Duration(0), c.SlowModeInterval)
+		assert.Equal(t, 24*time.Hour, c.IdempotencyKeyTTL)
+		assert.Equal(t, time.Minute, c.LoginCacheTTL)
+	})
+
+	t.Run("from-env", func(t *testing.T) {
+		os.Setenv("COMMENT_RETENTION", "48h")
+		os.Setenv("PURGE_BATCH_SIZE", "10")
+		os.Setenv("ADMIN_TOKEN", "secret")
+		os.Setenv("ORG_RATE_LIMIT", "5")
+		os.Setenv("SLOW_MODE_INTERVAL", "30s")
+		defer os.Unsetenv("COMMENT_RETENTION")
+		defer os.Unsetenv("PURGE_BATCH_SIZE")
+		defer os.Unsetenv("ADMIN_TOKEN")
+		defer os.Unsetenv("ORG_RATE_LIMIT")
+		defer os.Unsetenv("SLOW_MODE_INTERVAL")
+
+		c := load()
+		assert.Equal(t, 48*time.Hour, c.Retention)
+		assert.Equal(t, 10, c.PurgeBatchSize)
+		assert.Equal(t, "secret", c.AdminToken)
+		assert.Equal(t, 5, c.OrgRateLimit)
+		assert.Equal(t, 30*time.Second, c.SlowModeInterval)
+	})
+
+	t.Run("invalid-env", func(t *testing.T) {
+		os.Setenv("PURGE_INTERVAL", "often")
+		os.Setenv("PURGE_BATCH_SIZE", "-1")
+		defer os.Unsetenv("PURGE_INTERVAL")
+		defer os.Unsetenv("PURGE_BATCH_SIZE")
+
+		c := load()
+		assert.Equal(t, time.Hour, c.PurgeInterval)
+		assert.Equal(t, 500, c.PurgeBatchSize)
+	})
+
+	t.Run("disabled-limits", func(t *testing.T) {
+		os.Setenv("AUTHOR_RATE_LIMIT", "0")
+		os.Setenv("ORG_RATE_LIMIT", "-1")
+		os.Setenv("RATE_LIMIT_WINDOW", "0s")
+		os.Setenv("PURGE_INTERVAL", "0s")
+		os.Setenv("LOGIN_CACHE_TTL", "0s")
+		defer os.Unsetenv("AUTHOR_RATE_LIMIT")
+		defer os.Unsetenv("ORG_RATE_LIMIT")
+		defer os.Unsetenv("RATE_LIMIT_WINDOW")
+		defer os.Unsetenv("PURGE_INTERVAL")
+		defer os.Unsetenv("LOGIN_CACHE_TTL")
+
+		c := load()
+		assert.Equal(t, 0, c.AuthorRateLimit)
+		assert.Equal(t, 100, c.OrgRateLimit)
+		assert.Equal(t, time.Duration(0), c.RateLimitWindow)
+		assert.Equal(t, time.Duration(0), c.LoginCacheTTL)
+		// the purger can not be disabled.
+		assert.Equal(t, time.Hour, c.PurgeInterval)
+	})
+}
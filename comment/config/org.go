@@ -0,0 +1,215 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// OrgSettings holds the settings that can differ per org.
+type OrgSettings struct {
+	// FlagThreshold is the number of pending flags a comment can have. One more hides it until a
+	// moderator reviews it. Comments are never hidden automatically when it is zero.
+	FlagThreshold int `json:"flag_threshold"`
+
+	// Validation configures the rules new comments are checked against.
+	Validation ValidationSettings `json:"validation"`
+
+	// Secrets is what to do with comments containing secrets or emails, one of
+	// SecretsRedact, SecretsReject and SecretsAllow.
+	Secrets string `json:"secrets"`
+
+	// Tags configures the tags authors can put on their comments.
+	Tags TagSettings `json:"tags"`
+
+	// MaxPinned is the maximum number of comments org admins can pin, pinning is disabled when it is zero.
+	MaxPinned int `json:"max_pinned"`
+}
+
+// TagSettings configures comment tags.
+type TagSettings struct {
+	// MaxTags is the maximum number of tags of a comment, tags are disabled when it is zero.
+	MaxTags int `json:"max_tags"`
+	// Allowed restricts tags to the listed ones regardless of case. Any tag is allowed when it is empty.
+	Allowed []string `json:"allowed"`
+}
+
+// policies for comments containing secrets.
+const (
+	SecretsRedact = "redact"
+	SecretsReject = "reject"
+	SecretsAllow  = "allow"
+)
+
+// MaxCommentLength is the size of the comment column, no org can allow longer comments.
+const MaxCommentLength = 512
+
+// ValidationSettings configures the content rules for new comments. A zero limit disables its rule,
+// apart from MaxLength.
+type ValidationSettings struct {
+	// MaxLength is the maximum number of characters of a comment. It can only lower MaxCommentLength,
+	// which applies when it is zero, see CommentLength.
+	MaxLength int `json:"max_length"`
+	// BlockedWords are rejected as whole words regardless of case.
+	BlockedWords []string `json:"blocked_words"`
+	// BlockedPatterns are regular expressions rejected anywhere in a comment.
+	BlockedPatterns []string `json:"blocked_patterns"`
+	// MaxLinks is the maximum number of http(s) links in a comment.
+	MaxLinks int `json:"max_links"`
+	// DuplicateWindow is how long an author can not post the same comment to an org again.
+	DuplicateWindow Duration `json:"duplicate_window"`
+}
+
+// CommentLength returns the maximum number of characters of a comment, MaxLength capped at MaxCommentLength.
+func (v ValidationSettings) CommentLength() int {
+	if v.MaxLength <= 0 || v.MaxLength > MaxCommentLength {
+		return MaxCommentLength
+	}
+	return v.MaxLength
+}
+
+// Duration is a time.Duration read from a JSON string like "10m".
+type Duration time.Duration
+
+// UnmarshalJSON parses a duration string.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// defaultOrgSettings are used for the settings missing in the org settings file.
+var defaultOrgSettings = OrgSettings{
+	FlagThreshold: 3,
+	Validation: ValidationSettings{
+		MaxLength:       MaxCommentLength,
+		MaxLinks:        5,
+		DuplicateWindow: Duration(10 * time.Minute),
+	},
+	Secrets: SecretsRedact,
+	Tags: TagSettings{
+		MaxTags: 5,
+	},
+	MaxPinned: 3,
+}
+
+// orgSettingsFile is the format of the org settings file. Every org inherits
+// the settings it does not set from the defaults.
+//
+//	{
+//	    "default": {"flag_threshold": 3, "validation": {"max_links": 2}},
+//	    "orgs": {"github": {"flag_threshold": 5, "validation": {"blocked_words": ["darn"]}}}
+//	}
+type orgSettingsFile struct {
+	Default json.RawMessage            `json:"default"`
+	Orgs    map[string]json.RawMessage `json:"orgs"`
+}
+
+// ForOrg returns the settings of given org.
+func (c *Config) ForOrg(org string) OrgSettings {
+	if s, ok := c.Orgs[strings.ToLower(org)]; ok {
+		return s
+	}
+	return c.OrgDefaults
+}
+
+// loadOrgSettings reads the org settings file at given path. It falls back to the
+// built-in defaults if the path is empty or the file can not be read.
+func loadOrgSettings(path string) (OrgSettings, map[string]OrgSettings) {
+	if path == "" {
+		return defaultOrgSettings, nil
+	}
+
+	defaults, orgs, err := parseOrgSettings(path)
+	if err != nil {
+		log.Printf("ERROR: invalid org settings file %v, using defaults, err: %v", path, err)
+		return defaultOrgSettings, nil
+	}
+	return defaults, orgs
+}
+
+// parseOrgSettings parses the org settings file at given path.
+func parseOrgSettings(path string) (OrgSettings, map[string]OrgSettings, error) {
+	defaults := defaultOrgSettings
+
+	f, err := os.Open(path)
+	if err != nil {
+		return defaults, nil, err
+	}
+	defer f.Close()
+	data, err := ioutil.ReadAll(f)
+	if err != nil {
+		return defaults, nil, err
+	}
+
+	file := &orgSettingsFile{}
+	if err := json.Unmarshal(data, file); err != nil {
+		return defaults, nil, err
+	}
+	if len(file.Default) > 0 {
+		if err := json.Unmarshal(file.Default, &defaults); err != nil {
+			return defaults, nil, err
+		}
+	}
+
+	if err := defaults.validate(); err != nil {
+		return defaults, nil, err
+	}
+
+	orgs := make(map[string]OrgSettings, len(file.Orgs))
+	for org, raw := range file.Orgs {
+		s := defaults.clone()
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return defaults, nil, err
+		}
+		if err := s.validate(); err != nil {
+			return defaults, nil, fmt.Errorf("org %v: %v", org, err)
+		}
+		orgs[strings.ToLower(org)] = s
+	}
+	return defaults, orgs, nil
+}
+
+// clone returns a deep copy of the settings. Decoding into a copy sharing the slices
+// of the defaults would overwrite them.
+func (s OrgSettings) clone() OrgSettings {
+	s.Validation.BlockedWords = append([]string(nil), s.Validation.BlockedWords...)
+	s.Validation.BlockedPatterns = append([]string(nil), s.Validation.BlockedPatterns...)
+	s.Tags.Allowed = append([]string(nil), s.Tags.Allowed...)
+	return s
+}
+
+// validate checks the settings for values that can not be applied.
+func (s OrgSettings) validate() error {
+	v := s.Validation
+	if v.MaxLength < 0 || v.MaxLength > MaxCommentLength {
+		return fmt.Errorf("max_length must be between 0 and %v", MaxCommentLength)
+	}
+	if s.FlagThreshold < 0 || v.MaxLinks < 0 || v.DuplicateWindow < 0 || s.Tags.MaxTags < 0 || s.MaxPinned < 0 {
+		return errors.New("flag_threshold, max_links, duplicate_window, max_tags and max_pinned must not be negative")
+	}
+	switch s.Secrets {
+	case SecretsRedact, SecretsReject, SecretsAllow:
+	default:
+		return fmt.Errorf("secrets must be one of %v, %v, %v", SecretsRedact, SecretsReject, SecretsAllow)
+	}
+	for _, p := range v.BlockedPatterns {
+		if _, err := regexp.Compile(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// writeOrgSettings writes given content to a temporary org settings file and returns its path.
+func writeOrgSettings(t *testing.T, content string) string {
+	f, err := ioutil.TempFile("", "org-settings-*.json")
+	assert.NoError(t, err)
+	defer f.Close()
+	_, err = f.WriteString(content)
+	assert.NoError(t, err)
+	return f.Name()
+}
+
+func TestForOrg(t *testing.T) {
+	t.Run("defaults", func(t *testing.T) {
+		c := load()
+		assert.Equal(t, defaultOrgSettings, c.ForOrg("github"))
+	})
+
+	t.Run("from-file", func(t *testing.T) {
+		path := writeOrgSettings(t, `{
+			"default": {"flag_threshold": 4, "validation": {"max_links": 1}},
+			"orgs": {
+				"GitHub": {"flag_threshold": 10, "validation": {"blocked_words": ["darn"], "duplicate_window": "1h"}, "secrets": "reject", "max_pinned": 1},
+				"golang": {}
+			}
+		}`)
+		defer os.Remove(path)
+		os.Setenv("ORG_SETTINGS_FILE", path)
+		defer os.Unsetenv("ORG_SETTINGS_FILE")
+
+		c := load()
+		assert.Equal(t, 10, c.ForOrg("github").FlagThreshold)
+		assert.Equal(t, 4, c.ForOrg("golang").FlagThreshold)
+		assert.Equal(t, 4, c.ForOrg("rust-lang").FlagThreshold)
+
+		github := c.ForOrg("github").Validation
+		assert.Equal(t, []string{"darn"}, github.BlockedWords)
+		assert.Equal(t, Duration(time.Hour), github.DuplicateWindow)
+		assert.Equal(t, 1, github.MaxLinks)
+		assert.Equal(t, 512, github.MaxLength)
+		assert.Equal(t, Duration(10*time.Minute), c.ForOrg("golang").Validation.DuplicateWindow)
+		assert.Equal(t, SecretsReject, c.ForOrg("github").Secrets)
+		assert.Equal(t, SecretsRedact, c.ForOrg("golang").Secrets)
+		assert.Equal(t, 1, c.ForOrg("github").MaxPinned)
+		assert.Equal(t, 3, c.ForOrg("golang").MaxPinned)
+	})
+
+	t.Run("inherited-lists", func(t *testing.T) {
+		path := writeOrgSettings(t, `{
+			"default": {"validation": {"blocked_words": ["darn", "heck"]}},
+			"orgs": {"github": {"validation": {"blocked_words": ["gosh"]}}, "golang": {}}
+		}`)
+		defer os.Remove(path)
+		os.Setenv("ORG_SETTINGS_FILE", path)
+		defer os.Unsetenv("ORG_SETTINGS_FILE")
+
+		c := load()
+		assert.Equal(t, []string{"gosh"}, c.ForOrg("github").Validation.BlockedWords)
+		assert.Equal(t, []string{"darn", "heck"}, c.ForOrg("golang").Validation.BlockedWords)
+		assert.Equal(t, []string{"darn", "heck"}, c.ForOrg("rust-lang").Validation.BlockedWords)
+	})
+
+	t.Run("invalid-pattern", func(t *testing.T) {
+		path := writeOrgSettings(t, `{"orgs": {"github": {"validation": {"blocked_patterns": ["(unclosed"]}}}}`)
+		defer os.Remove(path)
+		os.Setenv("ORG_SETTINGS_FILE", path)
+		defer os.Unsetenv("ORG_SETTINGS_FILE")
+
+		c := load()
+		assert.Equal(t, defaultOrgSettings, c.ForOrg("github"))
+	})
+
+	t.Run("invalid-secrets", func(t *testing.T) {
+		path := writeOrgSettings(t, `{"orgs": {"github": {"secrets": "ignore"}}}`)
+		defer os.Remove(path)
+		os.Setenv("ORG_SETTINGS_FILE", path)
+		defer os.Unsetenv("ORG_SETTINGS_FILE")
+
+		c := load()
+		assert.Equal(t, SecretsRedact, c.ForOrg("github").Secrets)
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		path := writeOrgSettings(t, `{
+			"default": {"tags": {"max_tags": 3}},
+			"orgs": {"github": {"tags": {"allowed": ["incident", "q3"]}}, "golang": {}}
+		}`)
+		defer os.Remove(path)
+		os.Setenv("ORG_SETTINGS_FILE", path)
+		defer os.Unsetenv("ORG_SETTINGS_FILE")
+
+		c := load()
+		assert.Equal(t, TagSettings{MaxTags: 3, Allowed: []string{"incident", "q3"}}, c.ForOrg("github").Tags)
+		assert.Equal(t, TagSettings{MaxTags: 3}, c.ForOrg("golang").Tags)
+		assert.Equal(t, TagSettings{MaxTags: 3}, c.ForOrg("rust-lang").Tags)
+	})
+
+	t.Run("invalid-max-length", func(t *testing.T) {
+		path := writeOrgSettings(t, `{"default": {"validation": {"max_length": 1000}}}`)
+		defer os.Remove(path)
+		os.Setenv("ORG_SETTINGS_FILE", path)
+		defer os.Unsetenv("ORG_SETTINGS_FILE")
+
+		c := load()
+		assert.Equal(t, defaultOrgSettings, c.ForOrg("github"))
+	})
+
+	t.Run("invalid-file", func(t *testing.T) {
+		path := writeOrgSettings(t, `{"orgs": {"github": {"flag_threshold": "many"}}}`)
+		defer os.Remove(path)
+		os.Setenv("ORG_SETTINGS_FILE", path)
+		defer os.Unsetenv("ORG_SETTINGS_FILE")
+
+		c := load()
+		assert.Equal(t, defaultOrgSettings, c.ForOrg("github"))
+	})
+
+	t.Run("missing-file", func(t *testing.T) {
+		os.Setenv("ORG_SETTINGS_FILE", "/does/not/exist.json")
+		defer os.Unsetenv("ORG_SETTINGS_FILE")
+
+		c := load()
+		assert.Equal(t, defaultOrgSettings, c.ForOrg("github"))
+	})
+}
@@ -1,9 +1,109 @@
 CREATE TABLE comments (
   id SERIAL PRIMARY KEY,
   org VARCHAR(64) NOT NULL,
+  repo VARCHAR(100) NOT NULL DEFAULT '',
+  issue INTEGER NOT NULL DEFAULT 0,
   author VARCHAR(64) NOT NULL,
   comment VARCHAR(512) NOT NULL,
+  comment_html TEXT NOT NULL DEFAULT '',
+  parent_id INTEGER REFERENCES comments (id) ON DELETE SET NULL,
   is_deleted BOOLEAN DEFAULT FALSE,
+  deletion_batch VARCHAR(32),
+  deleted_by VARCHAR(64),
+  flag_count INTEGER NOT NULL DEFAULT 0,
+  is_hidden BOOLEAN DEFAULT FALSE,
+  reviewed_by VARCHAR(64),
+  reviewed_at TIMESTAMP,
+  pinned_at TIMESTAMP,
+  score INTEGER NOT NULL DEFAULT 0,
+  version INTEGER NOT NULL DEFAULT 1,
   created_at TIMESTAMP,
   updated_at TIMESTAMP
-);
\ No newline at end of file
+);
+
+CREATE INDEX comments_org_created_at_id_idx ON comments (org, created_at, id);
+CREATE INDEX comments_org_repo_issue_created_at_id_idx ON comments (org, repo, issue, created_at, id);
+CREATE INDEX comments_parent_id_idx ON comments (parent_id);
+CREATE INDEX comments_org_author_created_at_idx ON comments (org, author, created_at);
+CREATE INDEX comments_org_lower_author_created_at_idx ON comments (org, lower(author), created_at);
+CREATE INDEX comments_org_deletion_batch_idx ON comments (org, deletion_batch);
+CREATE INDEX comments_deleted_updated_at_idx ON comments (updated_at) WHERE is_deleted;
+CREATE INDEX comments_flagged_org_created_at_id_idx ON comments (org, created_at, id) WHERE flag_count > 0;
+CREATE INDEX comments_org_score_idx ON comments (org, score);
+CREATE INDEX comments_pinned_org_pinned_at_idx ON comments (org, pinned_at) WHERE pinned_at IS NOT NULL;
+
+CREATE TABLE comment_revisions (
+  id SERIAL PRIMARY KEY,
+  comment_id INTEGER NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+  comment VARCHAR(512) NOT NULL,
+  created_at TIMESTAMP,
+  replaced_at TIMESTAMP
+);
+
+CREATE INDEX comment_revisions_comment_id_idx ON comment_revisions (comment_id, id);
+
+CREATE TABLE comment_flags (
+  id SERIAL PRIMARY KEY,
+  comment_id INTEGER NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+  flagger VARCHAR(64) NOT NULL,
+  reason VARCHAR(256) NOT NULL,
+  created_at TIMESTAMP,
+  reviewed_at TIMESTAMP
+);
+
+-- a user can flag a comment again once their previous flag was reviewed.
+CREATE UNIQUE INDEX comment_flags_pending_comment_id_flagger_idx ON comment_flags (comment_id, flagger) WHERE reviewed_at IS NULL;
+
+CREATE TABLE comment_reactions (
+  id SERIAL PRIMARY KEY,
+  comment_id INTEGER NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+  reactor VARCHAR(64) NOT NULL,
+  content VARCHAR(16) NOT NULL,
+  created_at TIMESTAMP
+);
+
+-- a user can react to a comment once with every kind of reaction.
+CREATE UNIQUE INDEX comment_reactions_comment_id_reactor_content_idx ON comment_reactions (comment_id, reactor, content);
+
+CREATE TABLE comment_votes (
+  comment_id INTEGER NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+  voter VARCHAR(64) NOT NULL,
+  value SMALLINT NOT NULL CHECK (value IN (-1, 1)),
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP,
+  PRIMARY KEY (comment_id, voter)
+);
+
+CREATE TABLE comment_tags (
+  comment_id INTEGER NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+  org VARCHAR(64) NOT NULL,
+  tag VARCHAR(32) NOT NULL,
+  PRIMARY KEY (comment_id, tag)
+);
+
+CREATE INDEX comment_tags_org_tag_idx ON comment_tags (org, tag);
+
+CREATE TABLE comment_mentions (
+  comment_id INTEGER NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+  org VARCHAR(64) NOT NULL,
+  login VARCHAR(64) NOT NULL,
+  PRIMARY KEY (comment_id, login)
+);
+
+CREATE INDEX comment_mentions_login_comment_id_idx ON comment_mentions (login, comment_id);
+
+-- responses to requests made with an Idempotency-Key header, status is 0 while the first request is in progress.
+CREATE TABLE idempotent_responses (
+  idempotency_key VARCHAR(255) NOT NULL,
+  org VARCHAR(64) NOT NULL,
+  author VARCHAR(64) NOT NULL,
+  request_hash CHAR(64) NOT NULL,
+  status INTEGER NOT NULL DEFAULT 0,
+  body BYTEA,
+  headers JSONB,
+  created_at TIMESTAMP,
+  expires_at TIMESTAMP,
+  PRIMARY KEY (idempotency_key, org, author)
+);
+
+CREATE INDEX idempotent_responses_expires_at_idx ON idempotent_responses (expires_at);
@@ -0,0 +1,3 @@
+-- The user who deleted a comment. It is unknown for comments deleted before.
+ALTER TABLE comments
+  ADD COLUMN IF NOT EXISTS deleted_by VARCHAR(64);
@@ -0,0 +1,4 @@
+-- Filtering the comments of an org by author and creation time.
+CREATE INDEX IF NOT EXISTS comments_org_author_created_at_idx ON comments (org, author, created_at);
+-- Filtering the comments of an org by author regardless of case.
+CREATE INDEX IF NOT EXISTS comments_org_lower_author_created_at_idx ON comments (org, lower(author), created_at);
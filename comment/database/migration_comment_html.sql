@@ -0,0 +1,4 @@
+-- Comments rendered as sanitized HTML. Existing comments are left empty, their HTML is rendered and stored
+-- the first time they are read.
+ALTER TABLE comments
+  ADD COLUMN IF NOT EXISTS comment_html TEXT NOT NULL DEFAULT '';
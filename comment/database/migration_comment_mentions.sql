@@ -0,0 +1,9 @@
+-- @mentions of org members in comments. Mentions in existing comments are recorded when they are edited.
+CREATE TABLE IF NOT EXISTS comment_mentions (
+  comment_id INTEGER NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+  org VARCHAR(64) NOT NULL,
+  login VARCHAR(64) NOT NULL,
+  PRIMARY KEY (comment_id, login)
+);
+
+CREATE INDEX IF NOT EXISTS comment_mentions_login_comment_id_idx ON comment_mentions (login, comment_id);
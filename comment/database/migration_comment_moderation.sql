@@ -0,0 +1,20 @@
+-- Flagging and moderation. Existing comments start unflagged and shown.
+ALTER TABLE comments
+  ADD COLUMN IF NOT EXISTS flag_count INTEGER NOT NULL DEFAULT 0,
+  ADD COLUMN IF NOT EXISTS is_hidden BOOLEAN DEFAULT FALSE,
+  ADD COLUMN IF NOT EXISTS reviewed_by VARCHAR(64),
+  ADD COLUMN IF NOT EXISTS reviewed_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS comments_flagged_org_created_at_id_idx ON comments (org, created_at, id) WHERE flag_count > 0;
+
+CREATE TABLE IF NOT EXISTS comment_flags (
+  id SERIAL PRIMARY KEY,
+  comment_id INTEGER NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+  flagger VARCHAR(64) NOT NULL,
+  reason VARCHAR(256) NOT NULL,
+  created_at TIMESTAMP,
+  reviewed_at TIMESTAMP
+);
+
+-- a user can flag a comment again once their previous flag was reviewed.
+CREATE UNIQUE INDEX IF NOT EXISTS comment_flags_pending_comment_id_flagger_idx ON comment_flags (comment_id, flagger) WHERE reviewed_at IS NULL;
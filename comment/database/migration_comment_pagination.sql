@@ -0,0 +1,2 @@
+-- Keyset pagination of the comments of an org by creation time.
+CREATE INDEX IF NOT EXISTS comments_org_created_at_id_idx ON comments (org, created_at, id);
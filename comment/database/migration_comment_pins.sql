@@ -0,0 +1,5 @@
+-- Pinned comments. Existing comments are unpinned.
+ALTER TABLE comments
+  ADD COLUMN IF NOT EXISTS pinned_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS comments_pinned_org_pinned_at_idx ON comments (org, pinned_at) WHERE pinned_at IS NOT NULL;
@@ -0,0 +1,2 @@
+-- Finding the expired deleted comments to purge.
+CREATE INDEX IF NOT EXISTS comments_deleted_updated_at_idx ON comments (updated_at) WHERE is_deleted;
@@ -0,0 +1,11 @@
+-- Reactions to comments.
+CREATE TABLE IF NOT EXISTS comment_reactions (
+  id SERIAL PRIMARY KEY,
+  comment_id INTEGER NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+  reactor VARCHAR(64) NOT NULL,
+  content VARCHAR(16) NOT NULL,
+  created_at TIMESTAMP
+);
+
+-- a user can react to a comment once with every kind of reaction.
+CREATE UNIQUE INDEX IF NOT EXISTS comment_reactions_comment_id_reactor_content_idx ON comment_reactions (comment_id, reactor, content);
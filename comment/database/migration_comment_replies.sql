@@ -0,0 +1,5 @@
+-- Threaded replies. Existing comments become top level comments.
+ALTER TABLE comments
+  ADD COLUMN IF NOT EXISTS parent_id INTEGER REFERENCES comments (id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS comments_parent_id_idx ON comments (parent_id);
@@ -0,0 +1,10 @@
+-- Edit history of comments, one row per replaced text.
+CREATE TABLE IF NOT EXISTS comment_revisions (
+  id SERIAL PRIMARY KEY,
+  comment_id INTEGER NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+  comment VARCHAR(512) NOT NULL,
+  created_at TIMESTAMP,
+  replaced_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS comment_revisions_comment_id_idx ON comment_revisions (comment_id, id);
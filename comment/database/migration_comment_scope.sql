@@ -0,0 +1,6 @@
+-- Scoping comments to a repository or an issue of the org. Existing comments stay org wide.
+ALTER TABLE comments
+  ADD COLUMN IF NOT EXISTS repo VARCHAR(100) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS issue INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS comments_org_repo_issue_created_at_id_idx ON comments (org, repo, issue, created_at, id);
@@ -0,0 +1,6 @@
+-- Full-text search over comments. The tsvector column is generated by PostgreSQL,
+-- so it stays in sync with the comment text on every insert and update.
+ALTER TABLE comments
+  ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (to_tsvector('english', comment)) STORED;
+
+CREATE INDEX IF NOT EXISTS comments_search_vector_idx ON comments USING GIN (search_vector);
@@ -0,0 +1,9 @@
+-- Tags of comments. Existing comments are untagged.
+CREATE TABLE IF NOT EXISTS comment_tags (
+  comment_id INTEGER NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+  org VARCHAR(64) NOT NULL,
+  tag VARCHAR(32) NOT NULL,
+  PRIMARY KEY (comment_id, tag)
+);
+
+CREATE INDEX IF NOT EXISTS comment_tags_org_tag_idx ON comment_tags (org, tag);
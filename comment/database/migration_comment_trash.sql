@@ -0,0 +1,6 @@
+-- Deletion batches, so that comments deleted by the same request can be restored together.
+-- Comments deleted before have no batch and can only be restored by id or all at once.
+ALTER TABLE comments
+  ADD COLUMN IF NOT EXISTS deletion_batch VARCHAR(32);
+
+CREATE INDEX IF NOT EXISTS comments_org_deletion_batch_idx ON comments (org, deletion_batch);
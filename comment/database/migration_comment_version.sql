@@ -0,0 +1,3 @@
+-- Versions of comments for ETag preconditions. Existing comments start at version 1.
+ALTER TABLE comments
+  ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
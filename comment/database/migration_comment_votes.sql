@@ -0,0 +1,14 @@
+-- Votes on comments. The score of a comment is the sum of its votes, existing comments start at 0.
+ALTER TABLE comments
+  ADD COLUMN IF NOT EXISTS score INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS comments_org_score_idx ON comments (org, score);
+
+CREATE TABLE IF NOT EXISTS comment_votes (
+  comment_id INTEGER NOT NULL REFERENCES comments (id) ON DELETE CASCADE,
+  voter VARCHAR(64) NOT NULL,
+  value SMALLINT NOT NULL CHECK (value IN (-1, 1)),
+  created_at TIMESTAMP,
+  updated_at TIMESTAMP,
+  PRIMARY KEY (comment_id, voter)
+);
@@ -0,0 +1,19 @@
+-- Responses to requests made with an Idempotency-Key header, status is 0 while the first request is in progress.
+CREATE TABLE IF NOT EXISTS idempotent_responses (
+  idempotency_key VARCHAR(255) NOT NULL,
+  org VARCHAR(64) NOT NULL,
+  author VARCHAR(64) NOT NULL,
+  request_hash CHAR(64) NOT NULL,
+  status INTEGER NOT NULL DEFAULT 0,
+  body BYTEA,
+  headers JSONB,
+  created_at TIMESTAMP,
+  expires_at TIMESTAMP,
+  PRIMARY KEY (idempotency_key, org, author)
+);
+
+CREATE INDEX IF NOT EXISTS idempotent_responses_expires_at_idx ON idempotent_responses (expires_at);
+
+-- Headers of the stored responses, responses stored before have none.
+ALTER TABLE idempotent_responses
+  ADD COLUMN IF NOT EXISTS headers JSONB;
@@ -0,0 +1,172 @@
+package logic
+
+import (
+	"crypto/sha256"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/external/github"
+)
+
+const (
+	// loginKey caches the authenticated login in the request context.
+	loginKey = "github-proxy/login"
+	// tokenKey caches the bearer token of the caller in the request context.
+	tokenKey = "github-proxy/token"
+)
+
+var (
+	errMissingToken   = errors.New("missing bearer token")
+	errAuthorMismatch = errors.New("author does not match the authenticated user")
+	errNotOrgAdmin    = errors.New("only org admins can do this")
+)
+
+// currentUser resolves the Github login of the caller from the bearer token and writes
+// the error response if it can not be resolved. The login is cached in the request context,
+// and for LoginCacheTTL across requests, see loginCache.
+func (h *handlerImpl) currentUser(ctx *gin.Context) (string, bool) {
+	if login := ctx.GetString(loginKey); login != "" {
+		return login, true
+	}
+
+	token := bearerToken(ctx)
+	if token == "" {
+		handlerError(ctx, http.StatusUnauthorized, errMissingToken)
+		return "", false
+	}
+
+	login, err := h.authenticatedUser(ctx, token)
+	if err == github.ErrUnauthorized {
+		handlerError(ctx, http.StatusUnauthorized, err)
+		return "", false
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return "", false
+	}
+
+	ctx.Set(loginKey, login)
+	ctx.Set(tokenKey, token)
+	return login, true
+}
+
+// authenticatedUser returns the Github login of given token, from the login cache if it was
+// resolved recently. Failures are not cached.
+func (h *handlerImpl) authenticatedUser(ctx *gin.Context, token string) (string, error) {
+	if h.logins.ttl <= 0 {
+		return h.github.GetAuthenticatedUser(ctx, token)
+	}
+
+	key := sha256.Sum256([]byte(token))
+	if login, ok := h.logins.get(key, time.Now()); ok {
+		return login, nil
+	}
+	login, err := h.github.GetAuthenticatedUser(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	h.logins.put(key, login, time.Now())
+	return login, nil
+}
+
+// loginCache maps the hashes of bearer tokens to their Github logins for ttl, so that the tokens
+// themselves are not kept in memory. It caches nothing if ttl is not positive.
+type loginCache struct {
+	ttl time.Duration
+
+	mu        sync.Mutex
+	logins    map[[sha256.Size]byte]cachedLogin
+	lastSweep time.Time
+}
+
+// cachedLogin is a login along with the time it expires at.
+type cachedLogin struct {
+	login     string
+	expiresAt time.Time
+}
+
+// get returns the login of given token hash unless it is missing or expired.
+func (c *loginCache) get(key [sha256.Size]byte, now time.Time) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	l, ok := c.logins[key]
+	if !ok || !now.Before(l.expiresAt) {
+		return "", false
+	}
+	return l.login, true
+}
+
+// put caches the login of given token hash. It forgets expired logins at most once per ttl,
+// so that the memory used is bounded by the tokens seen within a ttl.
+func (c *loginCache) put(key [sha256.Size]byte, login string, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.logins == nil {
+		c.logins = map[[sha256.Size]byte]cachedLogin{}
+	}
+	if now.Sub(c.lastSweep) >= c.ttl {
+		c.lastSweep = now
+		for k, l := range c.logins {
+			if !now.Before(l.expiresAt) {
+				delete(c.logins, k)
+			}
+		}
+	}
+	c.logins[key] = cachedLogin{login: login, expiresAt: now.Add(c.ttl)}
+}
+
+// orgAdmin authenticates the caller and checks that they are an admin of given org,
+// writing the error response otherwise. It returns the login of the admin.
+func (h *handlerImpl) orgAdmin(ctx *gin.Context, org string) (string, bool) {
+	login, ok := h.currentUser(ctx)
+	if !ok {
+		return "", false
+	}
+
+	isAdmin, ok := h.isOrgAdmin(ctx, org)
+	if !ok {
+		return "", false
+	}
+	if !isAdmin {
+		log.Printf("INFO: user %v is not an admin of org %v", login, org)
+		handlerError(ctx, http.StatusForbidden, errNotOrgAdmin)
+		return "", false
+	}
+	return login, true
+}
+
+// isOrgAdmin checks whether the authenticated caller is an admin of given org and writes
+// the error response if Github can not tell. It must be called after currentUser.
+func (h *handlerImpl) isOrgAdmin(ctx *gin.Context, org string) (bool, bool) {
+	isAdmin, err := h.github.IsOrgAdmin(ctx, ctx.GetString(tokenKey), org)
+	if err == github.ErrUnauthorized {
+		handlerError(ctx, http.StatusUnauthorized, err)
+		return false, false
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return false, false
+	}
+	return isAdmin, true
+}
+
+// bearerToken returns the token of the Authorization header, if any.
+func bearerToken(ctx *gin.Context) string {
+	auth := ctx.GetHeader("Authorization")
+	if len(auth) < len("Bearer ") || !strings.EqualFold(auth[:len("Bearer ")], "Bearer ") {
+		return ""
+	}
+	return strings.TrimSpace(auth[len("Bearer "):])
+}
+
+// sameLogin compares Github logins, which are case insensitive.
+func sameLogin(a, b string) bool {
+	return strings.EqualFold(a, b)
+}
@@ -0,0 +1,94 @@
+package logic
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCurrentUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	h := &handlerImpl{
+		github: githubMock,
+		logins: loginCache{ttl: time.Minute},
+	}
+	current := func(token string) (string, int) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+		ctx.Request.Header.Set("Authorization", "Bearer "+token)
+		login, _ := h.currentUser(ctx)
+		return login, respWriter.Code
+	}
+
+	t.Run("cached", func(t *testing.T) {
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		login, _ := current("t0ken")
+		assert.Equal(t, "awesome-user", login)
+		login, _ = current("t0ken")
+		assert.Equal(t, "awesome-user", login)
+	})
+
+	t.Run("other-token", func(t *testing.T) {
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "other-t0ken").Return("other-user", nil).Once()
+		login, _ := current("other-t0ken")
+		assert.Equal(t, "other-user", login)
+	})
+
+	t.Run("failure-not-cached", func(t *testing.T) {
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "new-t0ken").Return("", github.ErrUnauthorized).Once()
+		_, code := current("new-t0ken")
+		assert.Equal(t, http.StatusUnauthorized, code)
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "new-t0ken").Return("awesome-user", nil).Once()
+		login, _ := current("new-t0ken")
+		assert.Equal(t, "awesome-user", login)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		h := &handlerImpl{github: githubMock}
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Twice()
+		for i := 0; i < 2; i++ {
+			respWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(respWriter)
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+			ctx.Request.Header.Set("Authorization", "Bearer t0ken")
+			login, ok := h.currentUser(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, "awesome-user", login)
+		}
+	})
+
+	githubMock.AssertExpectations(t)
+}
+
+func TestLoginCache(t *testing.T) {
+	c := &loginCache{ttl: time.Minute}
+	now := time.Now()
+	alice := sha256.Sum256([]byte("alice-t0ken"))
+	bob := sha256.Sum256([]byte("bob-t0ken"))
+
+	_, ok := c.get(alice, now)
+	assert.False(t, ok)
+
+	c.put(alice, "alice", now)
+	login, ok := c.get(alice, now.Add(59*time.Second))
+	assert.True(t, ok)
+	assert.Equal(t, "alice", login)
+	_, ok = c.get(alice, now.Add(time.Minute))
+	assert.False(t, ok)
+
+	// the expired login of alice is swept once bob is cached a ttl later.
+	c.put(bob, "bob", now.Add(time.Minute))
+	assert.Len(t, c.logins, 1)
+	_, ok = c.get(bob, now.Add(time.Minute))
+	assert.True(t, ok)
+}
@@ -3,6 +3,7 @@ package logic
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
 	"net/http"
@@ -12,24 +13,60 @@ import (
 	"github.com/rahulbharuka/github-proxy/comment/repository"
 )
 
-// PostComment posts a comment for the org
-func (h *handlerImpl) PostComment(ctx *gin.Context) {
-	org := ctx.Param("org")
+// maxPostBodyBytes is the maximum size of a JSON request body, e.g. a posted comment, well above a comment of 512 characters with its tags.
+const maxPostBodyBytes = 64 << 10
+
+var errPostBodyTooLarge = fmt.Errorf("request body must not be larger than %d KiB", maxPostBodyBytes>>10)
 
-	data, err := ioutil.ReadAll(ctx.Request.Body)
+// PostComment posts a comment for the org, or for a repository or an issue of it.
+func (h *handlerImpl) PostComment(ctx *gin.Context) {
+	scope, err := parseScope(ctx)
 	if err != nil {
-		log.Printf("ERROR: failed to read request body, err: %v", err)
 		handlerError(ctx, http.StatusBadRequest, err)
 		return
 	}
+	if !h.validScope(ctx, ctx.Param("org"), scope) {
+		return
+	}
+	h.postComment(ctx, scope, 0)
+}
+
+// postComment authenticates the author, scans and validates the comment from the request body
+// and saves it in given scope, as a reply to given parent if parentID is not zero.
+func (h *handlerImpl) postComment(ctx *gin.Context, scope repository.Scope, parentID uint64) {
+	org := ctx.Param("org")
+
+	login, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	data, ok := readPostBody(ctx)
+	if !ok {
+		return
+	}
 	c := &repository.Comment{}
-	err = json.Unmarshal(data, c)
+	err := json.Unmarshal(data, c)
 	if err != nil {
 		log.Printf("ERROR: failed to unmarshal request body, err: %v", err)
 		handlerError(ctx, http.StatusBadRequest, err)
 		return
 	}
+	if c.Author != "" && !sameLogin(c.Author, login) {
+		log.Printf("INFO: user %v tried to post as %v", login, c.Author)
+		handlerError(ctx, http.StatusForbidden, errAuthorMismatch)
+		return
+	}
+	c.ID = 0
 	c.Org = org
+	c.Repo = scope.Repo
+	c.Issue = scope.Issue
+	c.Author = login
+	c.ParentID = parentID
+
+	if !h.scanSecrets(ctx, c) || !h.validComment(ctx, c) || !h.validTags(ctx, c) {
+		return
+	}
 
 	isValid, err := h.github.IsMember(ctx, c.Org, c.Author)
 	if err != nil {
@@ -41,6 +78,9 @@ func (h *handlerImpl) PostComment(ctx *gin.Context) {
 		handlerError(ctx, http.StatusNotFound, errors.New("user is not a member of specified org"))
 		return
 	}
+	if !h.resolveMentions(ctx, c) {
+		return
+	}
 
 	err = h.commentRepo.Save(ctx, c)
 	if err != nil {
@@ -48,57 +88,143 @@ func (h *handlerImpl) PostComment(ctx *gin.Context) {
 		return
 	}
 
-	ctx.JSON(http.StatusOK, "added comment")
+	respondComment(ctx, http.StatusOK, c)
 }
 
-// ListAllComments fetches all comments for an org.
+// readPostBody reads a JSON request body, e.g. a posted or edited comment, up to maxPostBodyBytes, and writes
+// the error response if it can not be read.
+func readPostBody(ctx *gin.Context) ([]byte, bool) {
+	data, err := ioutil.ReadAll(http.MaxBytesReader(ctx.Writer, ctx.Request.Body, maxPostBodyBytes))
+	if err != nil && len(data) >= maxPostBodyBytes {
+		handlerError(ctx, http.StatusRequestEntityTooLarge, errPostBodyTooLarge)
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to read request body, err: %v", err)
+		handlerError(ctx, http.StatusBadRequest, err)
+		return nil, false
+	}
+	return data, true
+}
+
+// ListAllComments fetches a page of comments for an org.
 func (h *handlerImpl) ListAllComments(ctx *gin.Context) {
+	h.listComments(ctx, false)
+}
+
+// ListTrash lets an org admin fetch a page of soft-deleted comments for an org.
+func (h *handlerImpl) ListTrash(ctx *gin.Context) {
+	h.listComments(ctx, true)
+}
+
+// listComments fetches a page of either active or soft-deleted comments for an org, or for a
+// repository or an issue of it. Comments beneath the scope are included, e.g. those of the issues of a repository.
+// Only active comments can be listed as threads, and only their first page is led by the pinned comments.
+// A thread keeps its soft-deleted comments with active replies beneath them as tombstones.
+func (h *handlerImpl) listComments(ctx *gin.Context, deleted bool) {
 	org := ctx.Param("org")
 
-	isValid, err := h.github.IsValidOrg(ctx, org)
+	opts, err := listOptions(ctx)
 	if err != nil {
-		handlerError(ctx, http.StatusInternalServerError, err)
+		handlerError(ctx, http.StatusBadRequest, err)
 		return
 	}
-	if !isValid {
-		log.Printf("INFO: %v is not a valid Github org", org)
-		handlerError(ctx, http.StatusNotFound, errors.New("Specified org does not exist"))
+	opts.Deleted = deleted
+	opts.PinnedFirst = !deleted
+	opts.Scope, err = parseScope(ctx)
+	if err != nil {
+		handlerError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	var threaded bool
+	switch ctx.DefaultQuery("view", viewFlat) {
+	case viewFlat:
+	case viewThread:
+		threaded = !deleted
+		opts.RootsOnly = threaded
+	default:
+		handlerError(ctx, http.StatusBadRequest, errInvalidView)
+		return
+	}
+
+	format, err := commentFormat(ctx)
+	if err != nil {
+		handlerError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !h.validOrg(ctx, org) || !h.validScope(ctx, org, opts.Scope) {
 		return
 	}
+	if deleted {
+		if _, ok := h.orgAdmin(ctx, org); !ok {
+			return
+		}
+	}
 
-	comments, err := h.commentRepo.ListAll(ctx, org)
+	// fetch one extra comment to find out whether there is a next page.
+	limit := opts.Limit
+	opts.Limit++
+	comments, err := h.commentRepo.ListAll(ctx, org, opts)
 	if err != nil {
 		handlerError(ctx, http.StatusInternalServerError, err)
 		return
 	}
 
-	resp := make([]*model.Comment, len(comments))
-	for i, c := range comments {
-		resp[i] = &model.Comment{
-			Author:    c.Author,
-			Comment:   c.Comment,
-			CreatedAt: c.CreatedAt,
+	// pinned comments come first and do not count towards the limit.
+	pinned := 0
+	for pinned < len(comments) && !comments[pinned].PinnedAt.IsZero() {
+		pinned++
+	}
+
+	resp := &model.CommentList{}
+	if len(comments)-pinned > limit {
+		comments = comments[:pinned+limit]
+		resp.NextCursor = nextCursor(opts, limit, &comments[len(comments)-1])
+	}
+
+	resp.Comments = make([]*model.Comment, len(comments))
+	for i := range comments {
+		if threaded {
+			resp.Comments[i] = toThreadModel(&comments[i], format)
+		} else {
+			resp.Comments[i] = toFormattedModel(&comments[i], format)
 		}
 	}
 
+	if threaded {
+		err = h.attachReplies(ctx, org, resp.Comments, format)
+		if err != nil {
+			handlerError(ctx, http.StatusInternalServerError, err)
+			return
+		}
+	}
+
+	etag, err := listETag(resp)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if notModified(ctx, etag) {
+		return
+	}
 	ctx.JSON(http.StatusOK, resp)
 }
 
-// DeleteAllComments soft deletes all comments for an org.
+// DeleteAllComments lets an org admin soft delete all comments for an org.
 func (h *handlerImpl) DeleteAllComments(ctx *gin.Context) {
 	org := ctx.Param("org")
-	isValid, err := h.github.IsValidOrg(ctx, org)
-	if err != nil {
-		handlerError(ctx, http.StatusInternalServerError, err)
+	if !h.validOrg(ctx, org) {
 		return
 	}
-	if !isValid {
-		log.Printf("INFO: %v is not a valid Github org", org)
-		handlerError(ctx, http.StatusNotFound, errors.New("Specified org does not exist"))
+
+	login, ok := h.orgAdmin(ctx, org)
+	if !ok {
 		return
 	}
 
-	err = h.commentRepo.DeleteAll(ctx, org)
+	batch, err := h.commentRepo.DeleteAll(ctx, org, login)
 	if err == repository.ErrNoData {
 		handlerError(ctx, http.StatusNoContent, err)
 		return
@@ -108,5 +234,221 @@ func (h *handlerImpl) DeleteAllComments(ctx *gin.Context) {
 		handlerError(ctx, http.StatusInternalServerError, err)
 		return
 	}
-	ctx.JSON(http.StatusOK, "deleted all comments !")
+	ctx.JSON(http.StatusOK, &model.Deletion{Message: "deleted all comments !", DeletionBatch: batch})
+}
+
+// GetComment fetches a single comment of an org.
+func (h *handlerImpl) GetComment(ctx *gin.Context) {
+	org := ctx.Param("org")
+	id, ok := commentID(ctx)
+	if !ok || !h.validOrg(ctx, org) {
+		return
+	}
+
+	c, err := h.commentRepo.Get(ctx, org, id)
+	if err == repository.ErrNotFound {
+		handlerError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if !h.visibleComment(ctx, org, c) {
+		return
+	}
+
+	resp := toCommentModel(c)
+	etag, err := commentETag(c.Version, resp)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if notModified(ctx, etag) {
+		return
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// UpdateComment lets the author correct the text of a single comment of an org.
+// The If-Match header must carry the current ETag of the comment.
+func (h *handlerImpl) UpdateComment(ctx *gin.Context) {
+	org := ctx.Param("org")
+	id, ok := commentID(ctx)
+	if !ok {
+		return
+	}
+
+	login, ok := h.currentUser(ctx)
+	if !ok || !requireIfMatch(ctx) {
+		return
+	}
+
+	data, ok := readPostBody(ctx)
+	if !ok {
+		return
+	}
+	c := &repository.Comment{}
+	err := json.Unmarshal(data, c)
+	if err != nil {
+		log.Printf("ERROR: failed to unmarshal request body, err: %v", err)
+		handlerError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if c.Comment == "" {
+		handlerError(ctx, http.StatusBadRequest, errors.New("comment must not be empty"))
+		return
+	}
+	c.ID = id
+	c.Org = org
+
+	if !h.scanSecrets(ctx, c) || !h.validComment(ctx, c) || !h.validOrg(ctx, org) {
+		return
+	}
+
+	existing, err := h.commentRepo.Get(ctx, org, id)
+	if err == repository.ErrNotFound {
+		handlerError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if !sameLogin(existing.Author, login) {
+		log.Printf("INFO: user %v is not the author of comment %v", login, id)
+		handlerError(ctx, http.StatusForbidden, errors.New("only the author can edit a comment"))
+		return
+	}
+	if !checkIfMatch(ctx, existing) || !h.resolveMentions(ctx, c) {
+		return
+	}
+
+	c.Version = existing.Version
+	// tags are set when the comment is posted, an edit keeps them along with the reactions.
+	// The mentions follow the edited text.
+	c.Tags = existing.Tags
+	c.Reactions = existing.Reactions
+	err = h.commentRepo.Update(ctx, c)
+	if err == repository.ErrNotFound {
+		handlerError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if err == repository.ErrVersionMismatch {
+		handlerError(ctx, http.StatusPreconditionFailed, errETagMismatch)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondComment(ctx, http.StatusOK, c)
+}
+
+// DeleteComment lets the author or an org admin soft delete a single comment of an org.
+// The If-Match header must carry the current ETag of the comment.
+func (h *handlerImpl) DeleteComment(ctx *gin.Context) {
+	org := ctx.Param("org")
+	id, ok := commentID(ctx)
+	if !ok || !h.validOrg(ctx, org) {
+		return
+	}
+
+	login, ok := h.currentUser(ctx)
+	if !ok || !requireIfMatch(ctx) {
+		return
+	}
+
+	c, err := h.commentRepo.Get(ctx, org, id)
+	if err == repository.ErrNotFound {
+		handlerError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if !sameLogin(c.Author, login) {
+		isAdmin, ok := h.isOrgAdmin(ctx, org)
+		if !ok {
+			return
+		}
+		if !isAdmin {
+			log.Printf("INFO: user %v is neither the author of comment %v nor an admin of org %v", login, id, org)
+			handlerError(ctx, http.StatusForbidden, errors.New("only the author or an org admin can delete a comment"))
+			return
+		}
+	}
+
+	if !checkIfMatch(ctx, c) {
+		return
+	}
+
+	batch, err := h.commentRepo.Delete(ctx, org, id, c.Version, login)
+	if err == repository.ErrNotFound {
+		handlerError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if err == repository.ErrVersionMismatch {
+		handlerError(ctx, http.StatusPreconditionFailed, errETagMismatch)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	// the deletion is a change of the comment, it can be restored from the trash.
+	ctx.Header("ETag", versionETag(c.Version+1))
+	ctx.JSON(http.StatusOK, &model.Deletion{Message: "deleted comment !", DeletionBatch: batch})
+}
+
+// writeComment responds with given comment as it is stored, along with its ETag.
+func (h *handlerImpl) writeComment(ctx *gin.Context, org string, id uint64, status int) {
+	c, err := h.commentRepo.Get(ctx, org, id)
+	if err == repository.ErrNotFound {
+		handlerError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	respondComment(ctx, status, c)
+}
+
+// respondComment responds with given comment along with its ETag.
+func respondComment(ctx *gin.Context, status int, c *repository.Comment) {
+	resp := toCommentModel(c)
+	etag, err := commentETag(c.Version, resp)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.Header("ETag", etag)
+	ctx.JSON(status, resp)
+}
+
+// toCommentModel converts the storage object into the API model.
+func toCommentModel(c *repository.Comment) *model.Comment {
+	return &model.Comment{
+		ID:        c.ID,
+		Repo:      c.Repo,
+		Issue:     c.Issue,
+		Author:    c.Author,
+		Comment:   c.Comment,
+		Tags:      c.Tags,
+		Mentions:  c.Mentions,
+		Score:     c.Score,
+		Reactions: c.Reactions,
+		ParentID:  c.ParentID,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		Hidden:    c.IsHidden,
+		Pinned:    !c.PinnedAt.IsZero(),
+
+		DeletionBatch: c.DeletionBatch,
+		DeletedBy:     c.DeletedBy,
+	}
 }
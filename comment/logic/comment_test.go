@@ -6,11 +6,13 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
 	"github.com/rahulbharuka/github-proxy/comment/repository"
 
 	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/config"
 	"github.com/rahulbharuka/github-proxy/external/github"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -29,17 +31,87 @@ func TestListAllComments(t *testing.T) {
 		respWriter := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(respWriter)
 		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments", nil)
 
 		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
-		commentRepoMock.On("ListAll", mock.Anything, mock.Anything).Return([]repository.Comment{}, nil).Once()
+		commentRepoMock.On("ListAll", mock.Anything, mock.Anything, repository.ListOptions{Limit: defaultPageLimit + 1, PinnedFirst: true}).Return([]repository.Comment{}, nil).Once()
 		h.ListAllComments(ctx)
 		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.NotContains(t, respWriter.Body.String(), "next_cursor")
+	})
+
+	t.Run("not-modified", func(t *testing.T) {
+		comments := []repository.Comment{{ID: 1, Author: "awesome-user", Comment: "hello", Version: 1}}
+		list := func(ifNoneMatch string) *httptest.ResponseRecorder {
+			respWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(respWriter)
+			ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+			ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments", nil)
+			ctx.Request.Header.Set("If-None-Match", ifNoneMatch)
+
+			githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+			commentRepoMock.On("ListAll", mock.Anything, mock.Anything, mock.Anything).Return(comments, nil).Once()
+			h.ListAllComments(ctx)
+			return respWriter
+		}
+
+		respWriter := list("")
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		etag := respWriter.Header().Get("ETag")
+		assert.NotEmpty(t, etag)
+
+		respWriter = list(etag)
+		assert.Equal(t, http.StatusNotModified, respWriter.Code)
+		assert.Empty(t, respWriter.Body.String())
+
+		comments[0].Comment = "edited"
+		respWriter = list(etag)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.NotEqual(t, etag, respWriter.Header().Get("ETag"))
+	})
+
+	t.Run("next-page", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		cursor := &repository.Cursor{ID: 1}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments?limit=2&cursor="+encodeCursor(cursor), nil)
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		commentRepoMock.On("ListAll", mock.Anything, mock.Anything, mock.MatchedBy(func(opts repository.ListOptions) bool {
+			return opts.Limit == 3 && opts.After != nil && opts.After.ID == 1
+		})).Return([]repository.Comment{{ID: 2}, {ID: 3}, {ID: 4}}, nil).Once()
+		h.ListAllComments(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"next_cursor":"`+encodeCursor(&repository.Cursor{ID: 3})+`"`)
+		assert.NotContains(t, respWriter.Body.String(), `"id":4`)
+	})
+
+	t.Run("bad-limit", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments?limit=1000", nil)
+
+		h.ListAllComments(ctx)
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	t.Run("bad-cursor", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments?cursor=garbage", nil)
+
+		h.ListAllComments(ctx)
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
 	})
 
 	t.Run("github-api-err", func(t *testing.T) {
 		respWriter := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(respWriter)
 		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments", nil)
 
 		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(false, errors.New("some github error")).Once()
 		h.ListAllComments(ctx)
@@ -50,9 +122,10 @@ func TestListAllComments(t *testing.T) {
 		respWriter := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(respWriter)
 		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments", nil)
 
 		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
-		commentRepoMock.On("ListAll", mock.Anything, mock.Anything).Return([]repository.Comment{}, errors.New("some repo error")).Once()
+		commentRepoMock.On("ListAll", mock.Anything, mock.Anything, mock.Anything).Return([]repository.Comment{}, errors.New("some repo error")).Once()
 		h.ListAllComments(ctx)
 		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
 	})
@@ -71,11 +144,39 @@ func TestDeleteAllComments(t *testing.T) {
 		respWriter := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(respWriter)
 		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = &http.Request{Header: authHeader}
 
 		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
-		commentRepoMock.On("DeleteAll", mock.Anything, mock.Anything).Return(nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-admin", nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(true, nil).Once()
+		commentRepoMock.On("DeleteAll", mock.Anything, "github", "awesome-admin").Return("0a1b2c", nil).Once()
 		h.DeleteAllComments(ctx)
 		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"deletion_batch":"0a1b2c"`)
+	})
+
+	t.Run("missing-token", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = &http.Request{Header: http.Header{}}
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		h.DeleteAllComments(ctx)
+		assert.Equal(t, http.StatusUnauthorized, respWriter.Code)
+	})
+
+	t.Run("not-admin", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = &http.Request{Header: authHeader}
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(false, nil).Once()
+		h.DeleteAllComments(ctx)
+		assert.Equal(t, http.StatusForbidden, respWriter.Code)
 	})
 
 	t.Run("github-api-err", func(t *testing.T) {
@@ -92,9 +193,12 @@ func TestDeleteAllComments(t *testing.T) {
 		respWriter := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(respWriter)
 		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = &http.Request{Header: authHeader}
 
 		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
-		commentRepoMock.On("DeleteAll", mock.Anything, mock.Anything).Return(errors.New("some repo error")).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-admin", nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(true, nil).Once()
+		commentRepoMock.On("DeleteAll", mock.Anything, "github", "awesome-admin").Return("", errors.New("some repo error")).Once()
 		h.DeleteAllComments(ctx)
 		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
 	})
@@ -107,29 +211,104 @@ func TestPostComment(t *testing.T) {
 	h := &handlerImpl{
 		github:      githubMock,
 		commentRepo: commentRepoMock,
+		config:      &config.Config{},
 	}
 
 	t.Run("happy-path", func(t *testing.T) {
 		respWriter := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(respWriter)
 		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
-		jsonBody := `{"author":"awesome.user","comment":"test comment"}`
+		jsonBody := `{"author":"awesome-user","comment":"test comment"}`
 		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
-		ctx.Request = &http.Request{Body: body}
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
 
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
 		githubMock.On("IsMember", mock.Anything, mock.Anything, mock.Anything).Return(true, nil).Once()
 		commentRepoMock.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
 		h.PostComment(ctx)
 		assert.Equal(t, http.StatusOK, respWriter.Code)
 	})
 
-	t.Run("bad-request-body", func(t *testing.T) {
+	t.Run("author-from-token", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		jsonBody := `{"comment":"test comment"}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("Save", mock.Anything, mock.MatchedBy(func(c *repository.Comment) bool {
+			return c.Author == "awesome-user"
+		})).Return(nil).Once()
+		h.PostComment(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+	})
+
+	t.Run("forged-deletion-state", func(t *testing.T) {
 		respWriter := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(respWriter)
-		jsonBody := `{"author":"awesome.user","comment":"test comment"}}`
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		jsonBody := `{"comment":"test comment","is_deleted":true,"deletion_batch":"forged","deleted_by":"someone"}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("Save", mock.Anything, mock.MatchedBy(func(c *repository.Comment) bool {
+			return !c.IsDeleted && c.DeletionBatch == "" && c.DeletedBy == ""
+		})).Return(nil).Once()
+		h.PostComment(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+	})
+
+	t.Run("missing-token", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		jsonBody := `{"author":"awesome-user","comment":"test comment"}`
 		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
 		ctx.Request = &http.Request{Body: body}
 
+		h.PostComment(ctx)
+		assert.Equal(t, http.StatusUnauthorized, respWriter.Code)
+	})
+
+	t.Run("invalid-token", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		jsonBody := `{"author":"awesome-user","comment":"test comment"}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("", github.ErrUnauthorized).Once()
+		h.PostComment(ctx)
+		assert.Equal(t, http.StatusUnauthorized, respWriter.Code)
+	})
+
+	t.Run("author-mismatch", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		jsonBody := `{"author":"other-user","comment":"test comment"}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		h.PostComment(ctx)
+		assert.Equal(t, http.StatusForbidden, respWriter.Code)
+	})
+
+	t.Run("bad-request-body", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		jsonBody := `{"author":"awesome-user","comment":"test comment"}}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
 		h.PostComment(ctx)
 		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
 	})
@@ -137,10 +316,11 @@ func TestPostComment(t *testing.T) {
 	t.Run("github-api-error", func(t *testing.T) {
 		respWriter := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(respWriter)
-		jsonBody := `{"author":"awesome.user","comment":"test comment"}`
+		jsonBody := `{"author":"awesome-user","comment":"test comment"}`
 		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
-		ctx.Request = &http.Request{Body: body}
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
 
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
 		githubMock.On("IsMember", mock.Anything, mock.Anything, mock.Anything).Return(false, errors.New("some github error")).Once()
 		h.PostComment(ctx)
 		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
@@ -150,10 +330,11 @@ func TestPostComment(t *testing.T) {
 		respWriter := httptest.NewRecorder()
 		ctx, _ := gin.CreateTestContext(respWriter)
 		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
-		jsonBody := `{"author":"awesome.user","comment":"test comment"}`
+		jsonBody := `{"author":"awesome-user","comment":"test comment"}`
 		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
-		ctx.Request = &http.Request{Body: body}
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
 
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
 		githubMock.On("IsMember", mock.Anything, mock.Anything, mock.Anything).Return(true, nil).Once()
 		commentRepoMock.On("Save", mock.Anything, mock.Anything).Return(errors.New("save error")).Once()
 		h.PostComment(ctx)
@@ -161,3 +342,352 @@ func TestPostComment(t *testing.T) {
 	})
 
 }
+
+func TestGetComment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+
+	t.Run("happy-path", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		ctx.Request = &http.Request{Header: http.Header{}}
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1, Version: 3}, nil).Once()
+		h.GetComment(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"id":1`)
+		assert.Regexp(t, `^W/"3-[0-9a-f]{16}"$`, respWriter.Header().Get("ETag"))
+	})
+
+	t.Run("not-modified", func(t *testing.T) {
+		get := func(ifNoneMatch string, c *repository.Comment) *httptest.ResponseRecorder {
+			respWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(respWriter)
+			ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+			ctx.Request = &http.Request{Header: http.Header{"If-None-Match": []string{ifNoneMatch}}}
+
+			githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+			commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(c, nil).Once()
+			h.GetComment(ctx)
+			return respWriter
+		}
+
+		respWriter := get("", &repository.Comment{ID: 1, Version: 3})
+		etag := respWriter.Header().Get("ETag")
+
+		respWriter = get(`"2", `+etag, &repository.Comment{ID: 1, Version: 3})
+		assert.Equal(t, http.StatusNotModified, respWriter.Code)
+		assert.Empty(t, respWriter.Body.String())
+
+		// a vote does not change the version, but the comment is modified.
+		respWriter = get(etag, &repository.Comment{ID: 1, Version: 3, Score: 1})
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.NotEqual(t, etag, respWriter.Header().Get("ETag"))
+	})
+
+	t.Run("hidden", func(t *testing.T) {
+		cases := []struct {
+			name    string
+			header  http.Header
+			isAdmin bool
+			code    int
+		}{
+			{"anonymous", http.Header{}, false, http.StatusNotFound},
+			{"member", authHeader, false, http.StatusNotFound},
+			{"admin", authHeader, true, http.StatusOK},
+		}
+		for _, tc := range cases {
+			respWriter := httptest.NewRecorder()
+			ctx, _ := gin.CreateTestContext(respWriter)
+			ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+			ctx.Request = &http.Request{Header: tc.header}
+
+			githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+			commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1, Version: 1, IsHidden: true}, nil).Once()
+			if len(tc.header) > 0 {
+				githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+				githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(tc.isAdmin, nil).Once()
+			}
+			h.GetComment(ctx)
+			assert.Equal(t, tc.code, respWriter.Code, tc.name)
+		}
+	})
+
+	t.Run("bad-id", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "abc"}}
+
+		h.GetComment(ctx)
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(nil, repository.ErrNotFound).Once()
+		h.GetComment(ctx)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+}
+
+func TestUpdateComment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+		config:      &config.Config{},
+	}
+
+	t.Run("happy-path", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		jsonBody := `{"comment":"edited comment"}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: editHeader}
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1, Author: "awesome-user", Version: 1}, nil).Once()
+		commentRepoMock.On("Update", mock.Anything, mock.MatchedBy(func(c *repository.Comment) bool {
+			return c.ID == 1 && c.Org == "github" && c.Comment == "edited comment" && c.Version == 1
+		})).Run(func(args mock.Arguments) {
+			args.Get(1).(*repository.Comment).Version = 2
+		}).Return(nil).Once()
+		h.UpdateComment(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Regexp(t, `^W/"2-[0-9a-f]{16}"$`, respWriter.Header().Get("ETag"))
+	})
+
+	t.Run("body-too-large", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		jsonBody := `{"comment":"` + strings.Repeat("x", maxPostBodyBytes) + `"}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: editHeader}
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		h.UpdateComment(ctx)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, respWriter.Code)
+	})
+
+	t.Run("missing-if-match", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		jsonBody := `{"comment":"edited comment"}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		h.UpdateComment(ctx)
+		assert.Equal(t, http.StatusPreconditionRequired, respWriter.Code)
+	})
+
+	t.Run("stale-etag", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		jsonBody := `{"comment":"edited comment"}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: editHeader}
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1, Author: "awesome-user", Version: 2}, nil).Once()
+		h.UpdateComment(ctx)
+		assert.Equal(t, http.StatusPreconditionFailed, respWriter.Code)
+	})
+
+	t.Run("concurrent-update", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		jsonBody := `{"comment":"edited comment"}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: editHeader}
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1, Author: "awesome-user", Version: 1}, nil).Once()
+		commentRepoMock.On("Update", mock.Anything, mock.Anything).Return(repository.ErrVersionMismatch).Once()
+		h.UpdateComment(ctx)
+		assert.Equal(t, http.StatusPreconditionFailed, respWriter.Code)
+	})
+
+	t.Run("empty-comment", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		jsonBody := `{"comment":""}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: editHeader}
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		h.UpdateComment(ctx)
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	t.Run("not-the-author", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		jsonBody := `{"comment":"edited comment"}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: editHeader}
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1, Author: "other-user", Version: 1}, nil).Once()
+		h.UpdateComment(ctx)
+		assert.Equal(t, http.StatusForbidden, respWriter.Code)
+	})
+
+	t.Run("missing-token", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		jsonBody := `{"comment":"edited comment"}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body}
+
+		h.UpdateComment(ctx)
+		assert.Equal(t, http.StatusUnauthorized, respWriter.Code)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		jsonBody := `{"comment":"edited comment"}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: editHeader}
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(nil, repository.ErrNotFound).Once()
+		h.UpdateComment(ctx)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+}
+
+func TestDeleteComment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+
+	t.Run("happy-path", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		ctx.Request = &http.Request{Header: editHeader}
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1, Author: "awesome-user", Version: 1}, nil).Once()
+		commentRepoMock.On("Delete", mock.Anything, "github", uint64(1), 1, "awesome-user").Return("0a1b2c", nil).Once()
+		h.DeleteComment(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Equal(t, `W/"2"`, respWriter.Header().Get("ETag"))
+	})
+
+	t.Run("if-match-of-get", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		ctx.Request = &http.Request{Header: http.Header{"Authorization": []string{"Bearer t0ken"}, "If-Match": []string{`W/"1-0123456789abcdef"`}}}
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1, Author: "awesome-user", Version: 1, Score: 5}, nil).Once()
+		commentRepoMock.On("Delete", mock.Anything, "github", uint64(1), 1, "awesome-user").Return("0a1b2c", nil).Once()
+		h.DeleteComment(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+	})
+
+	t.Run("missing-if-match", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		ctx.Request = &http.Request{Header: authHeader}
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		h.DeleteComment(ctx)
+		assert.Equal(t, http.StatusPreconditionRequired, respWriter.Code)
+	})
+
+	t.Run("concurrent-update", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		ctx.Request = &http.Request{Header: editHeader}
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1, Author: "awesome-user", Version: 1}, nil).Once()
+		commentRepoMock.On("Delete", mock.Anything, "github", uint64(1), 1, "awesome-user").Return("", repository.ErrVersionMismatch).Once()
+		h.DeleteComment(ctx)
+		assert.Equal(t, http.StatusPreconditionFailed, respWriter.Code)
+	})
+
+	t.Run("org-admin", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		ctx.Request = &http.Request{Header: editHeader}
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-admin", nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1, Author: "awesome-user", Version: 1}, nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(true, nil).Once()
+		commentRepoMock.On("Delete", mock.Anything, "github", uint64(1), 1, "awesome-admin").Return("0a1b2c", nil).Once()
+		h.DeleteComment(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+	})
+
+	t.Run("not-author", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		ctx.Request = &http.Request{Header: editHeader}
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("someone-else", nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1, Author: "awesome-user", Version: 1}, nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(false, nil).Once()
+		h.DeleteComment(ctx)
+		assert.Equal(t, http.StatusForbidden, respWriter.Code)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		ctx.Request = &http.Request{Header: editHeader}
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(nil, repository.ErrNotFound).Once()
+		h.DeleteComment(ctx)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+}
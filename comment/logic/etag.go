@@ -0,0 +1,119 @@
+package logic
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+var (
+	errMissingIfMatch = errors.New("If-Match header with the ETag of the comment is required")
+	errETagMismatch   = errors.New("comment was changed, fetch it again and retry with its new ETag")
+)
+
+// commentETag returns the weak entity tag of a comment at given version, represented by resp.
+// The tag starts with the version, which is all If-Match compares, so that votes, reactions, pins and
+// moderation do not fail the edits of the author. The hash of resp following it makes If-None-Match notice them.
+// The tag is weak since If-Match does not compare it as a whole, which a strong tag would require.
+func commentETag(version int, resp interface{}) (string, error) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return "W/" + strconv.Quote(strconv.Itoa(version)+"-"+hex.EncodeToString(sum[:8])), nil
+}
+
+// versionETag returns the weak entity tag of a comment at given version that has no representation, e.g. once deleted.
+func versionETag(version int) string {
+	return "W/" + strconv.Quote(strconv.Itoa(version))
+}
+
+// etagVersion returns the version of a comment entity tag, or -1 if the tag is not one.
+func etagVersion(tag string) int {
+	tag, err := strconv.Unquote(tag)
+	if err != nil {
+		return -1
+	}
+	if i := strings.IndexByte(tag, '-'); i >= 0 {
+		tag = tag[:i]
+	}
+	version, err := strconv.Atoi(tag)
+	if err != nil {
+		return -1
+	}
+	return version
+}
+
+// requireIfMatch writes the error response if the request has no If-Match header.
+// Writes to a single comment must name the version they are based on.
+func requireIfMatch(ctx *gin.Context) bool {
+	if ctx.GetHeader("If-Match") == "" {
+		handlerError(ctx, http.StatusPreconditionRequired, errMissingIfMatch)
+		return false
+	}
+	return true
+}
+
+// checkIfMatch writes the error response unless the If-Match header names the current version of c.
+// Only the version of the weak comment tags is compared, see commentETag.
+func checkIfMatch(ctx *gin.Context, c *repository.Comment) bool {
+	match := func(tag string) bool { return etagVersion(tag) == c.Version }
+	if !etagMatches(ctx.GetHeader("If-Match"), match, true) {
+		handlerError(ctx, http.StatusPreconditionFailed, errETagMismatch)
+		return false
+	}
+	return true
+}
+
+// notModified writes an empty 304 response if the If-None-Match header matches given entity tag,
+// otherwise it sets the ETag header of the response.
+func notModified(ctx *gin.Context, etag string) bool {
+	ctx.Header("ETag", etag)
+	match := func(tag string) bool { return tag == strings.TrimPrefix(etag, "W/") }
+	if etagMatches(ctx.GetHeader("If-None-Match"), match, true) {
+		ctx.Status(http.StatusNotModified)
+		ctx.Writer.WriteHeaderNow()
+		return true
+	}
+	return false
+}
+
+// etagMatches tells whether any of the comma separated entity tags of a precondition header matches.
+// Weak tags only match when weak comparison is allowed. Given match is called with the opaque tag,
+// i.e. without a W/ prefix.
+func etagMatches(header string, match func(tag string) bool, weak bool) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" {
+			return true
+		}
+		if strings.HasPrefix(tag, "W/") {
+			if !weak {
+				continue
+			}
+			tag = strings.TrimPrefix(tag, "W/")
+		}
+		if match(tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// listETag returns the entity tag of a page of comments, which changes along with any comment on the page.
+func listETag(resp interface{}) (string, error) {
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return strconv.Quote(hex.EncodeToString(sum[:16])), nil
+}
@@ -0,0 +1,237 @@
+package logic
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+const (
+	formatCSV      = "csv"
+	formatNDJSON   = "ndjson"
+	formatMarkdown = "md"
+
+	// exportFlushRows is how many rows are written between two flushes to the client.
+	exportFlushRows = 100
+)
+
+var errInvalidFormat = errors.New("format must be one of csv, ndjson, md")
+
+// exporter writes comments in one export format.
+type exporter interface {
+	contentType() string
+	begin(org string) error
+	write(c *repository.Comment) error
+	end() error
+}
+
+// newExporter returns the exporter of given format writing to w.
+func newExporter(format string, w io.Writer) (exporter, error) {
+	switch format {
+	case formatCSV:
+		return &csvExporter{w: csv.NewWriter(w)}, nil
+	case formatNDJSON:
+		return &ndjsonExporter{enc: json.NewEncoder(w)}, nil
+	case formatMarkdown:
+		return &markdownExporter{w: w}, nil
+	default:
+		return nil, errInvalidFormat
+	}
+}
+
+// ExportComments streams all comments of an org as CSV, NDJSON or Markdown.
+// Org admins can include deleted and hidden comments.
+func (h *handlerImpl) ExportComments(ctx *gin.Context) {
+	org := ctx.Param("org")
+
+	format := ctx.DefaultQuery("format", formatNDJSON)
+	exp, err := newExporter(format, ctx.Writer)
+	if err != nil {
+		handlerError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	includeDeleted, err := strconv.ParseBool(ctx.DefaultQuery("include_deleted", "false"))
+	if err != nil {
+		handlerError(ctx, http.StatusBadRequest, errors.New("include_deleted must be true or false"))
+		return
+	}
+
+	if !h.validOrg(ctx, org) {
+		return
+	}
+	if includeDeleted {
+		if _, ok := h.orgAdmin(ctx, org); !ok {
+			return
+		}
+	}
+
+	filename := fmt.Sprintf("%s-comments-%s.%s", org, time.Now().UTC().Format("20060102"), format)
+	ctx.Header("Content-Type", exp.contentType())
+	ctx.Header("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	ctx.Status(http.StatusOK)
+
+	// the status is sent with the first row, later errors can only cut the export short.
+	rows := 0
+	err = exp.begin(org)
+	if err == nil {
+		err = h.commentRepo.Export(ctx, org, repository.ExportOptions{All: includeDeleted}, func(c *repository.Comment) error {
+			if err := exp.write(c); err != nil {
+				return err
+			}
+			rows++
+			if rows%exportFlushRows == 0 {
+				ctx.Writer.Flush()
+			}
+			return nil
+		})
+	}
+	if err == nil {
+		err = exp.end()
+	}
+	if err != nil {
+		log.Printf("ERROR: export of comments for org %v stopped after %v rows, err: %v", org, rows, err)
+		return
+	}
+	log.Printf("INFO: exported %v comments for org %v as %v", rows, org, format)
+}
+
+// csvFormulaStart holds the characters that make a spreadsheet read a cell as a formula.
+const csvFormulaStart = "=+-@\t\r"
+
+// csvCell guards a user-controlled CSV value against being read as a formula by a spreadsheet,
+// prefixing it with a quote. Values already starting with quotes before such a character get
+// one more, so that csvValue always restores the original value.
+func csvCell(value string) string {
+	if trimmed := strings.TrimLeft(value, "'"); trimmed != "" && strings.IndexByte(csvFormulaStart, trimmed[0]) >= 0 {
+		return "'" + value
+	}
+	return value
+}
+
+// csvValue reverts csvCell.
+func csvValue(cell string) string {
+	if strings.HasPrefix(cell, "'") && csvCell(cell[1:]) == cell {
+		return cell[1:]
+	}
+	return cell
+}
+
+// csvExporter writes one comment per CSV record after a header record.
+// User-controlled values are guarded by csvCell.
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func (e *csvExporter) contentType() string {
+	return "text/csv; charset=utf-8"
+}
+
+func (e *csvExporter) begin(org string) error {
+	return e.w.Write([]string{"id", "parent_id", "author", "comment", "created_at", "updated_at", "hidden", "is_deleted", "deletion_batch", "deleted_by", "repo", "issue"})
+}
+
+func (e *csvExporter) write(c *repository.Comment) error {
+	parentID, issue := "", ""
+	if c.ParentID != 0 {
+		parentID = strconv.FormatUint(c.ParentID, 10)
+	}
+	if c.Issue != 0 {
+		issue = strconv.Itoa(c.Issue)
+	}
+	return e.w.Write([]string{
+		strconv.FormatUint(c.ID, 10),
+		parentID,
+		csvCell(c.Author),
+		csvCell(c.Comment),
+		c.CreatedAt.Format(time.RFC3339),
+		c.UpdatedAt.Format(time.RFC3339),
+		strconv.FormatBool(c.IsHidden),
+		strconv.FormatBool(c.IsDeleted),
+		c.DeletionBatch,
+		csvCell(c.DeletedBy),
+		csvCell(c.Repo),
+		issue,
+	})
+}
+
+func (e *csvExporter) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// ndjsonExporter writes one comment per line in the JSON format of the API.
+// Deleted comments are marked as deleted, comments deleted before deletion batches have no other trace of it.
+type ndjsonExporter struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonExporter) contentType() string {
+	return "application/x-ndjson"
+}
+
+func (e *ndjsonExporter) begin(org string) error {
+	return nil
+}
+
+func (e *ndjsonExporter) write(c *repository.Comment) error {
+	m := toCommentModel(c)
+	m.Deleted = c.IsDeleted
+	return e.enc.Encode(m)
+}
+
+func (e *ndjsonExporter) end() error {
+	return nil
+}
+
+// markdownExporter writes a readable document with one section per comment.
+type markdownExporter struct {
+	w io.Writer
+}
+
+func (e *markdownExporter) contentType() string {
+	return "text/markdown; charset=utf-8"
+}
+
+func (e *markdownExporter) begin(org string) error {
+	_, err := fmt.Fprintf(e.w, "# Comments of %s\n", org)
+	return err
+}
+
+func (e *markdownExporter) write(c *repository.Comment) error {
+	var notes []string
+	if c.ParentID != 0 {
+		notes = append(notes, fmt.Sprintf("reply to #%d", c.ParentID))
+	}
+	if c.IsHidden {
+		notes = append(notes, "hidden")
+	}
+	if c.IsDeleted && c.DeletedBy != "" {
+		notes = append(notes, "deleted by "+c.DeletedBy)
+	} else if c.IsDeleted {
+		notes = append(notes, "deleted")
+	}
+	suffix := ""
+	if len(notes) > 0 {
+		suffix = " (" + strings.Join(notes, ", ") + ")"
+	}
+
+	// quote every line so that the comment can not break the structure of the document.
+	quoted := "> " + strings.Replace(c.Comment, "\n", "\n> ", -1)
+	_, err := fmt.Fprintf(e.w, "\n## #%d by %s on %s%s\n\n%s\n", c.ID, c.Author, c.CreatedAt.Format(time.RFC3339), suffix, quoted)
+	return err
+}
+
+func (e *markdownExporter) end() error {
+	return nil
+}
@@ -0,0 +1,121 @@
+package logic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExportComments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+	createdAt := time.Date(2020, 2, 21, 13, 0, 0, 0, time.UTC)
+	comments := []repository.Comment{
+		{ID: 1, Author: "octocat", Comment: "hello, \"world\"", CreatedAt: createdAt, UpdatedAt: createdAt},
+		{ID: 2, Author: "hubot", Comment: "first line\nsecond line", ParentID: 1, CreatedAt: createdAt, UpdatedAt: createdAt,
+			IsDeleted: true, DeletionBatch: "0a1b2c", DeletedBy: "octocat", Repo: "hub", Issue: 42},
+		// deleted before deletion batches and deleted_by were recorded.
+		{ID: 3, Author: "hubot", Comment: "old", CreatedAt: createdAt, UpdatedAt: createdAt, IsDeleted: true},
+	}
+	streamComments := func(args mock.Arguments) {
+		fn := args.Get(3).(func(*repository.Comment) error)
+		for i := range comments {
+			_ = fn(&comments[i])
+		}
+	}
+	export := func(url string, header http.Header) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, url, nil)
+		ctx.Request.Header = header
+		h.ExportComments(ctx)
+		return respWriter
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		commentRepoMock.On("Export", mock.Anything, "github", repository.ExportOptions{}, mock.Anything).Run(streamComments).Return(nil).Once()
+		respWriter := export("/orgs/github/comments/export?format=csv", http.Header{})
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", respWriter.Header().Get("Content-Type"))
+		assert.Regexp(t, `^attachment; filename=github-comments-\d{8}\.csv$`, respWriter.Header().Get("Content-Disposition"))
+		assert.Equal(t, "id,parent_id,author,comment,created_at,updated_at,hidden,is_deleted,deletion_batch,deleted_by,repo,issue\n"+
+			"1,,octocat,\"hello, \"\"world\"\"\",2020-02-21T13:00:00Z,2020-02-21T13:00:00Z,false,false,,,,\n"+
+			"2,1,hubot,\"first line\nsecond line\",2020-02-21T13:00:00Z,2020-02-21T13:00:00Z,false,true,0a1b2c,octocat,hub,42\n"+
+			"3,,hubot,old,2020-02-21T13:00:00Z,2020-02-21T13:00:00Z,false,true,,,,\n",
+			respWriter.Body.String())
+	})
+
+	t.Run("ndjson", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		commentRepoMock.On("Export", mock.Anything, "github", repository.ExportOptions{}, mock.Anything).Run(streamComments).Return(nil).Once()
+		respWriter := export("/orgs/github/comments/export", http.Header{})
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Equal(t, "application/x-ndjson", respWriter.Header().Get("Content-Type"))
+		assert.Equal(t, `{"id":1,"author":"octocat","comment":"hello, \"world\"","created_at":"2020-02-21T13:00:00Z","updated_at":"2020-02-21T13:00:00Z","score":0}`+"\n"+
+			`{"id":2,"repo":"hub","issue":42,"author":"hubot","comment":"first line\nsecond line","parent_id":1,"created_at":"2020-02-21T13:00:00Z","updated_at":"2020-02-21T13:00:00Z","score":0,"deletion_batch":"0a1b2c","deleted_by":"octocat","deleted":true}`+"\n"+
+			`{"id":3,"author":"hubot","comment":"old","created_at":"2020-02-21T13:00:00Z","updated_at":"2020-02-21T13:00:00Z","score":0,"deleted":true}`+"\n",
+			respWriter.Body.String())
+	})
+
+	t.Run("markdown-with-deleted", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-admin", nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(true, nil).Once()
+		commentRepoMock.On("Export", mock.Anything, "github", repository.ExportOptions{All: true}, mock.Anything).Run(streamComments).Return(nil).Once()
+		respWriter := export("/orgs/github/comments/export?format=md&include_deleted=true", authHeader)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Equal(t, "# Comments of github\n"+
+			"\n## #1 by octocat on 2020-02-21T13:00:00Z\n\n> hello, \"world\"\n"+
+			"\n## #2 by hubot on 2020-02-21T13:00:00Z (reply to #1, deleted by octocat)\n\n> first line\n> second line\n"+
+			"\n## #3 by hubot on 2020-02-21T13:00:00Z (deleted)\n\n> old\n",
+			respWriter.Body.String())
+	})
+
+	t.Run("deleted-not-admin", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(false, nil).Once()
+		respWriter := export("/orgs/github/comments/export?include_deleted=true", authHeader)
+		assert.Equal(t, http.StatusForbidden, respWriter.Code)
+	})
+
+	t.Run("invalid-format", func(t *testing.T) {
+		respWriter := export("/orgs/github/comments/export?format=xml", http.Header{})
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+}
+
+func TestCSVCell(t *testing.T) {
+	cases := map[string]string{
+		"":          "",
+		"hello":     "hello",
+		"a=1":       "a=1",
+		"=1+1":      "'=1+1",
+		"+1":        "'+1",
+		"-1":        "'-1",
+		"@SUM(A1)":  "'@SUM(A1)",
+		"\t=1":      "'\t=1",
+		"\r=1":      "'\r=1",
+		"'quoted":   "'quoted",
+		"'=1+1":     "''=1+1",
+		"''@import": "'''@import",
+	}
+	for value, cell := range cases {
+		assert.Equal(t, cell, csvCell(value), value)
+		assert.Equal(t, value, csvValue(cell), cell)
+	}
+}
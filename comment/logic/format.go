@@ -0,0 +1,42 @@
+package logic
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/markdown"
+	"github.com/rahulbharuka/github-proxy/comment/model"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+// formats of the comment text in responses.
+const (
+	formatRaw  = "raw"
+	formatHTML = "html"
+	formatText = "text"
+)
+
+var errCommentFormat = errors.New("format must be one of raw, html, text")
+
+// commentFormat parses the format query param.
+func commentFormat(ctx *gin.Context) (string, error) {
+	switch format := ctx.DefaultQuery("format", formatRaw); format {
+	case formatRaw, formatHTML, formatText:
+		return format, nil
+	default:
+		return "", errCommentFormat
+	}
+}
+
+// toFormattedModel converts the storage object into the API model with its text in given format.
+// The HTML is cached by the repository, see repository.Comment.CommentHTML.
+func toFormattedModel(c *repository.Comment, format string) *model.Comment {
+	m := toCommentModel(c)
+	switch format {
+	case formatHTML:
+		m.Comment = c.CommentHTML
+	case formatText:
+		m.Comment = markdown.Text(c.Comment)
+	}
+	return m
+}
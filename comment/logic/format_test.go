@@ -0,0 +1,82 @@
+package logic
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/model"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListCommentsFormat(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+	comments := []repository.Comment{
+		{ID: 1, Comment: "**hi** <b>", CommentHTML: "<p><strong>hi</strong> &lt;b&gt;</p>"},
+		{ID: 2, Comment: "_old_", CommentHTML: "<p><em>old</em></p>"},
+	}
+	list := func(query string) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments"+query, nil)
+		h.ListAllComments(ctx)
+		return respWriter
+	}
+	texts := func(respWriter *httptest.ResponseRecorder) []string {
+		resp := &model.CommentList{}
+		assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), resp))
+		var texts []string
+		for _, c := range resp.Comments {
+			texts = append(texts, c.Comment)
+		}
+		return texts
+	}
+
+	cases := map[string][]string{
+		"":             {"**hi** <b>", "_old_"},
+		"?format=raw":  {"**hi** <b>", "_old_"},
+		"?format=html": {"<p><strong>hi</strong> &lt;b&gt;</p>", "<p><em>old</em></p>"},
+		"?format=text": {"hi <b>", "old"},
+	}
+	for query, want := range cases {
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		commentRepoMock.On("ListAll", mock.Anything, "github", mock.Anything).Return(comments, nil).Once()
+		respWriter := list(query)
+		assert.Equal(t, http.StatusOK, respWriter.Code, query)
+		assert.Equal(t, want, texts(respWriter), query)
+	}
+
+	t.Run("thread", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		commentRepoMock.On("ListAll", mock.Anything, "github", mock.Anything).Return(comments[:1], nil).Once()
+		commentRepoMock.On("ListReplies", mock.Anything, "github", []uint64{1}).
+			Return([]repository.Comment{{ID: 3, ParentID: 1, Comment: "`x`", CommentHTML: "<p><code>x</code></p>"}}, nil).Once()
+		respWriter := list("?view=thread&format=html")
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		resp := &model.CommentList{}
+		assert.NoError(t, json.Unmarshal(respWriter.Body.Bytes(), resp))
+		if assert.Len(t, resp.Comments, 1) && assert.Len(t, resp.Comments[0].Replies, 1) {
+			assert.Equal(t, "<p><code>x</code></p>", resp.Comments[0].Replies[0].Comment)
+		}
+	})
+
+	t.Run("invalid-format", func(t *testing.T) {
+		respWriter := list("?format=md")
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
@@ -1,7 +1,19 @@
 package logic
 
 import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
 	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/config"
+	"github.com/rahulbharuka/github-proxy/comment/ratelimit"
 	"github.com/rahulbharuka/github-proxy/comment/repository"
 	"github.com/rahulbharuka/github-proxy/external/github"
 )
@@ -11,19 +23,68 @@ type Handler interface {
 	PostComment(ctx *gin.Context)
 	ListAllComments(ctx *gin.Context)
 	DeleteAllComments(ctx *gin.Context)
+	GetComment(ctx *gin.Context)
+	UpdateComment(ctx *gin.Context)
+	DeleteComment(ctx *gin.Context)
+	PostReply(ctx *gin.Context)
+	SearchComments(ctx *gin.Context)
+	ListRevisions(ctx *gin.Context)
+	ListTrash(ctx *gin.Context)
+	RestoreComments(ctx *gin.Context)
+	PurgeComments(ctx *gin.Context)
+	RunPurger(ctx context.Context)
+	LimitPosts(ctx *gin.Context)
+	Idempotent(ctx *gin.Context)
+	FlagComment(ctx *gin.Context)
+	ModerationQueue(ctx *gin.Context)
+	ApproveComment(ctx *gin.Context)
+	HideComment(ctx *gin.Context)
+	ExportComments(ctx *gin.Context)
+	ImportComments(ctx *gin.Context)
+	ListTags(ctx *gin.Context)
+	PinComment(ctx *gin.Context)
+	UnpinComment(ctx *gin.Context)
+	ReactToComment(ctx *gin.Context)
+	RemoveReaction(ctx *gin.Context)
+	VoteComment(ctx *gin.Context)
+	ListMentions(ctx *gin.Context)
 }
 
 // handlerImpl is a implementation of Handler interface
 type handlerImpl struct {
 	commentRepo repository.CommentRepo
 	github      github.Handler
+	config      *config.Config
+
+	// purgeMu prevents the background and manually triggered purges from overlapping.
+	purgeMu sync.Mutex
+
+	// logins caches the Github logins of bearer tokens, see currentUser.
+	logins loginCache
+
+	// validators check new comments before they are saved, see validComment.
+	validators []CommentValidator
+
+	// limiters of posted comments, see LimitPosts.
+	authorLimiter *ratelimit.Limiter
+	orgLimiter    *ratelimit.Limiter
+	slowMode      *ratelimit.Limiter
 }
 
 // GetHandler initializes and returns the logic layer handler.
 func GetHandler() Handler {
+	cfg := config.Get()
+	commentRepo := repository.NewCommentRepo()
 	return &handlerImpl{
-		commentRepo: repository.NewCommentRepo(),
+		commentRepo: commentRepo,
 		github:      github.GetHandler(),
+		config:      cfg,
+		validators:  defaultValidators(commentRepo),
+		logins:      loginCache{ttl: cfg.LoginCacheTTL},
+
+		authorLimiter: ratelimit.New(cfg.AuthorRateLimit, cfg.RateLimitWindow),
+		orgLimiter:    ratelimit.New(cfg.OrgRateLimit, cfg.RateLimitWindow),
+		slowMode:      ratelimit.New(1, cfg.SlowModeInterval),
 	}
 }
 
@@ -33,3 +94,56 @@ func handlerError(ctx *gin.Context, errCode int, err error) {
 		"message": err.Error(),
 	})
 }
+
+// validOrg checks the org against Github and writes the error response if it is not valid.
+func (h *handlerImpl) validOrg(ctx *gin.Context, org string) bool {
+	isValid, err := h.github.IsValidOrg(ctx, org)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return false
+	}
+	if !isValid {
+		log.Printf("INFO: %v is not a valid Github org", org)
+		handlerError(ctx, http.StatusNotFound, errors.New("Specified org does not exist"))
+		return false
+	}
+	return true
+}
+
+// commentID parses the comment ID path param and writes the error response if it is malformed.
+func commentID(ctx *gin.Context) (uint64, bool) {
+	id, err := strconv.ParseUint(ctx.Param("id"), 10, 64)
+	if err != nil || id == 0 {
+		handlerError(ctx, http.StatusBadRequest, errors.New("invalid comment id"))
+		return 0, false
+	}
+	return id, true
+}
+
+// timeQuery parses an optional RFC3339 timestamp query param.
+func timeQuery(ctx *gin.Context, key string) (time.Time, error) {
+	v := ctx.Query(key)
+	if v == "" {
+		return time.Time{}, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%s must be an RFC3339 timestamp", key)
+	}
+	return t, nil
+}
+
+// isAdmin checks the admin token header against the configured one and writes the error response if it does not match.
+func (h *handlerImpl) isAdmin(ctx *gin.Context) bool {
+	if h.config.AdminToken == "" {
+		handlerError(ctx, http.StatusForbidden, errors.New("admin endpoints are disabled"))
+		return false
+	}
+	token := ctx.GetHeader("X-Admin-Token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(h.config.AdminToken)) != 1 {
+		handlerError(ctx, http.StatusForbidden, errors.New("invalid admin token"))
+		return false
+	}
+	return true
+}
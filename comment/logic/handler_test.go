@@ -1,6 +1,7 @@
 package logic
 
 import (
+	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,3 +12,9 @@ func TestGetHandler(t *testing.T) {
 	h2 := GetHandler()
 	assert.Equal(t, h1, h2)
 }
+
+// authHeader authenticates test requests, the token is resolved by the Github mock.
+var authHeader = http.Header{"Authorization": []string{"Bearer t0ken"}}
+
+// editHeader authenticates writes to a comment at version 1.
+var editHeader = http.Header{"Authorization": []string{"Bearer t0ken"}, "If-Match": []string{`W/"1"`}}
@@ -0,0 +1,171 @@
+package logic
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+const (
+	// idempotencyKeyHeader lets clients retry a request without repeating its effect.
+	idempotencyKeyHeader = "Idempotency-Key"
+	// idempotentReplayedHeader is set on responses replayed for a retried request.
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	// maxIdempotencyKeyLength is the length of the idempotency_key column of idempotent_responses.
+	maxIdempotencyKeyLength = 255
+)
+
+// replayedHeaders are the headers of a stored response that are replayed along with its status and body.
+// The rate limit headers are not, they tell the state of the limits at the time of a request.
+var replayedHeaders = []string{"Content-Type", "ETag", "Location"}
+
+var (
+	errInvalidIdempotencyKey = errors.New("Idempotency-Key must be at most 255 characters")
+	errIdempotencyKeyInUse   = errors.New("a request with this Idempotency-Key is in progress, please retry later")
+	errIdempotencyKeyReused  = errors.New("Idempotency-Key was already used with a different request")
+)
+
+// Idempotent is a middleware replaying the stored response of the first request made by the caller
+// with the same Idempotency-Key header to the same org, so that retried requests take effect once.
+// Requests without the header are passed on as they are. A key reused with a different body, or
+// for another route or scope of the org, e.g. another repo or issue, is rejected with 422.
+// Responses to failed (5xx), panicking or rate limited requests are not stored, so that they can
+// be retried with the same key.
+func (h *handlerImpl) Idempotent(ctx *gin.Context) {
+	key := strings.TrimSpace(ctx.GetHeader(idempotencyKeyHeader))
+	if key == "" {
+		ctx.Next()
+		return
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		handlerError(ctx, http.StatusBadRequest, errInvalidIdempotencyKey)
+		ctx.Abort()
+		return
+	}
+
+	login, ok := h.currentUser(ctx)
+	if !ok {
+		ctx.Abort()
+		return
+	}
+
+	data, ok := readPostBody(ctx)
+	if !ok {
+		ctx.Abort()
+		return
+	}
+	ctx.Request.Body = ioutil.NopCloser(bytes.NewReader(data))
+
+	now := time.Now()
+	resp := &repository.IdempotentResponse{
+		IdempotencyKey: key,
+		Org:            strings.ToLower(ctx.Param("org")),
+		Author:         strings.ToLower(login),
+		RequestHash:    requestHash(ctx, data),
+		CreatedAt:      now,
+		ExpiresAt:      now.Add(h.config.IdempotencyKeyTTL),
+	}
+	stored, err := h.commentRepo.ClaimIdempotencyKey(ctx, resp)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		ctx.Abort()
+		return
+	}
+	if stored != nil {
+		h.replay(ctx, resp, stored)
+		ctx.Abort()
+		return
+	}
+
+	recorder := &responseRecorder{ResponseWriter: ctx.Writer}
+	ctx.Writer = recorder
+	defer func() {
+		// a panic fails the request as well, release the key before passing it on to the recovery.
+		if err := recover(); err != nil {
+			_ = h.commentRepo.ReleaseIdempotencyKey(ctx, resp)
+			panic(err)
+		}
+	}()
+	ctx.Next()
+
+	resp.Status = recorder.Status()
+	if resp.Status == http.StatusTooManyRequests || resp.Status >= http.StatusInternalServerError {
+		_ = h.commentRepo.ReleaseIdempotencyKey(ctx, resp)
+		return
+	}
+	resp.Body = recorder.body.Bytes()
+	resp.Headers = http.Header{}
+	for _, name := range replayedHeaders {
+		if value := recorder.Header().Get(name); value != "" {
+			resp.Headers.Set(name, value)
+		}
+	}
+	_ = h.commentRepo.CompleteIdempotencyKey(ctx, resp)
+}
+
+// requestHash returns the hash of the method, matched route, URL params and body of a request,
+// so that a key is only replayed for the very same request.
+func requestHash(ctx *gin.Context, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s %s\n", ctx.Request.Method, ctx.FullPath())
+	for _, p := range ctx.Params {
+		fmt.Fprintf(hash, "%s=%s\n", p.Key, strings.ToLower(p.Value))
+	}
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// replay writes the stored response for a request retried with the same key.
+func (h *handlerImpl) replay(ctx *gin.Context, resp, stored *repository.IdempotentResponse) {
+	if stored.RequestHash != resp.RequestHash {
+		log.Printf("INFO: user %v reused idempotency key %q for org %v with a different request", resp.Author, resp.IdempotencyKey, resp.Org)
+		handlerError(ctx, http.StatusUnprocessableEntity, errIdempotencyKeyReused)
+		return
+	}
+	if stored.Status == 0 {
+		handlerError(ctx, http.StatusConflict, errIdempotencyKeyInUse)
+		return
+	}
+
+	log.Printf("INFO: replaying response to user %v for idempotency key %q of org %v", resp.Author, resp.IdempotencyKey, resp.Org)
+	ctx.Header(idempotentReplayedHeader, "true")
+	for _, name := range replayedHeaders {
+		if value := stored.Headers.Get(name); value != "" {
+			ctx.Header(name, value)
+		}
+	}
+	contentType := stored.Headers.Get("Content-Type")
+	if contentType == "" {
+		// stored before the headers were.
+		contentType = "application/json; charset=utf-8"
+	}
+	ctx.Data(stored.Status, contentType, stored.Body)
+}
+
+// responseRecorder keeps a copy of the body written to the client.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+// Write ...
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	w.body.Write(b)
+	return w.ResponseWriter.Write(b)
+}
+
+// WriteString ...
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,186 @@
+package logic
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/config"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIdempotent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+		config:      &config.Config{IdempotencyKeyTTL: time.Hour},
+	}
+	body := `{"author":"awesome-user","comment":"hello"}`
+	hash := sha256.Sum256([]byte("POST /orgs/:org/comments\norg=github\n" + body))
+	requestHash := hex.EncodeToString(hash[:])
+
+	// the next handler echoes the request body with given status and an ETag, counting its calls.
+	calls := 0
+	status := http.StatusCreated
+	router := gin.New()
+	echo := func(ctx *gin.Context) {
+		calls++
+		data, _ := ctx.GetRawData()
+		ctx.Header("ETag", `"1-0123456789abcdef"`)
+		ctx.Header("X-RateLimit-Remaining", "9")
+		ctx.Data(status, "application/json; charset=utf-8", data)
+	}
+	router.POST("/orgs/:org/comments", h.Idempotent, echo)
+	router.POST("/orgs/:org/repos/:repo/comments", h.Idempotent, echo)
+	postTo := func(path, key, body string) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header = http.Header{"Authorization": authHeader["Authorization"]}
+		if key != "" {
+			req.Header.Set("Idempotency-Key", key)
+			githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("Awesome-User", nil).Once()
+		}
+		router.ServeHTTP(respWriter, req)
+		return respWriter
+	}
+	post := func(key, body string) *httptest.ResponseRecorder {
+		return postTo("/orgs/GitHub/comments", key, body)
+	}
+	claimed := func(resp *repository.IdempotentResponse) bool {
+		return resp.IdempotencyKey == "k1" && resp.Org == "github" && resp.Author == "awesome-user" &&
+			resp.RequestHash == requestHash && resp.ExpiresAt.Sub(resp.CreatedAt) == time.Hour
+	}
+
+	t.Run("without-key", func(t *testing.T) {
+		calls = 0
+		respWriter := post("", body)
+		assert.Equal(t, http.StatusCreated, respWriter.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("first-request", func(t *testing.T) {
+		calls = 0
+		commentRepoMock.On("ClaimIdempotencyKey", mock.Anything, mock.MatchedBy(claimed)).Return(nil, nil).Once()
+		commentRepoMock.On("CompleteIdempotencyKey", mock.Anything, mock.MatchedBy(func(resp *repository.IdempotentResponse) bool {
+			return claimed(resp) && resp.Status == http.StatusCreated && string(resp.Body) == body &&
+				resp.Headers.Get("ETag") == `"1-0123456789abcdef"` && resp.Headers.Get("Content-Type") == "application/json; charset=utf-8" &&
+				resp.Headers.Get("X-RateLimit-Remaining") == ""
+		})).Return(nil).Once()
+		respWriter := post("k1", body)
+		assert.Equal(t, http.StatusCreated, respWriter.Code)
+		assert.Equal(t, body, respWriter.Body.String())
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		calls = 0
+		commentRepoMock.On("ClaimIdempotencyKey", mock.Anything, mock.MatchedBy(claimed)).
+			Return(&repository.IdempotentResponse{RequestHash: requestHash, Status: http.StatusCreated, Body: []byte(`{"id":1}`),
+				Headers: http.Header{"Etag": []string{`"1-0123456789abcdef"`}, "Location": []string{"/orgs/github/comments/1"}}}, nil).Once()
+		respWriter := post("k1", body)
+		assert.Equal(t, http.StatusCreated, respWriter.Code)
+		assert.Equal(t, `{"id":1}`, respWriter.Body.String())
+		assert.Equal(t, "true", respWriter.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, `"1-0123456789abcdef"`, respWriter.Header().Get("ETag"))
+		assert.Equal(t, "/orgs/github/comments/1", respWriter.Header().Get("Location"))
+		assert.Equal(t, "application/json; charset=utf-8", respWriter.Header().Get("Content-Type"))
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("different-body", func(t *testing.T) {
+		calls = 0
+		commentRepoMock.On("ClaimIdempotencyKey", mock.Anything, mock.Anything).
+			Return(&repository.IdempotentResponse{RequestHash: requestHash, Status: http.StatusCreated, Body: []byte(`{"id":1}`)}, nil).Once()
+		respWriter := post("k1", `{"author":"awesome-user","comment":"bye"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, respWriter.Code)
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("different-route", func(t *testing.T) {
+		calls = 0
+		commentRepoMock.On("ClaimIdempotencyKey", mock.Anything, mock.MatchedBy(func(resp *repository.IdempotentResponse) bool {
+			return resp.IdempotencyKey == "k1" && resp.Org == "github" && resp.RequestHash != requestHash
+		})).Return(&repository.IdempotentResponse{RequestHash: requestHash, Status: http.StatusCreated, Body: []byte(`{"id":1}`)}, nil).Once()
+		respWriter := postTo("/orgs/GitHub/repos/hub/comments", "k1", body)
+		assert.Equal(t, http.StatusUnprocessableEntity, respWriter.Code)
+		assert.Empty(t, respWriter.Header().Get("Idempotent-Replayed"))
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("different-repo", func(t *testing.T) {
+		calls = 0
+		hash := sha256.Sum256([]byte("POST /orgs/:org/repos/:repo/comments\norg=github\nrepo=hub\n" + body))
+		repoHash := hex.EncodeToString(hash[:])
+		commentRepoMock.On("ClaimIdempotencyKey", mock.Anything, mock.MatchedBy(func(resp *repository.IdempotentResponse) bool {
+			return resp.IdempotencyKey == "k1" && resp.RequestHash != repoHash && resp.RequestHash != requestHash
+		})).Return(&repository.IdempotentResponse{RequestHash: repoHash, Status: http.StatusCreated, Body: []byte(`{"id":1}`)}, nil).Once()
+		respWriter := postTo("/orgs/GitHub/repos/lab/comments", "k1", body)
+		assert.Equal(t, http.StatusUnprocessableEntity, respWriter.Code)
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("in-progress", func(t *testing.T) {
+		calls = 0
+		commentRepoMock.On("ClaimIdempotencyKey", mock.Anything, mock.MatchedBy(claimed)).
+			Return(&repository.IdempotentResponse{RequestHash: requestHash}, nil).Once()
+		respWriter := post("k1", body)
+		assert.Equal(t, http.StatusConflict, respWriter.Code)
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("failed-request", func(t *testing.T) {
+		calls = 0
+		status = http.StatusInternalServerError
+		defer func() { status = http.StatusCreated }()
+		commentRepoMock.On("ClaimIdempotencyKey", mock.Anything, mock.MatchedBy(claimed)).Return(nil, nil).Once()
+		commentRepoMock.On("ReleaseIdempotencyKey", mock.Anything, mock.MatchedBy(claimed)).Return(nil).Once()
+		respWriter := post("k1", body)
+		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		panicking := gin.New()
+		panicking.POST("/orgs/:org/comments", h.Idempotent, func(ctx *gin.Context) {
+			panic("boom")
+		})
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("Awesome-User", nil).Once()
+		commentRepoMock.On("ClaimIdempotencyKey", mock.Anything, mock.MatchedBy(claimed)).Return(nil, nil).Once()
+		commentRepoMock.On("ReleaseIdempotencyKey", mock.Anything, mock.MatchedBy(claimed)).Return(nil).Once()
+
+		req := httptest.NewRequest(http.MethodPost, "/orgs/GitHub/comments", strings.NewReader(body))
+		req.Header = http.Header{"Authorization": authHeader["Authorization"], "Idempotency-Key": []string{"k1"}}
+		assert.Panics(t, func() { panicking.ServeHTTP(httptest.NewRecorder(), req) })
+	})
+
+	t.Run("body-too-large", func(t *testing.T) {
+		calls = 0
+		respWriter := post("k1", `{"comment":"`+strings.Repeat("x", maxPostBodyBytes)+`"}`)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, respWriter.Code)
+		assert.Equal(t, 0, calls)
+	})
+
+	t.Run("key-too-long", func(t *testing.T) {
+		calls = 0
+		respWriter := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/orgs/github/comments", strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", strings.Repeat("k", 256))
+		router.ServeHTTP(respWriter, req)
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+		assert.Equal(t, 0, calls)
+	})
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
@@ -0,0 +1,376 @@
+package logic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/model"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+const (
+	// importMaxRows is the maximum number of comments accepted by one import.
+	importMaxRows = 10000
+	// importMaxBytes is the maximum size of an import body, well above importMaxRows comments of 512 characters.
+	importMaxBytes = 32 << 20
+	// importMaxLineBytes is the maximum size of a NDJSON line, well above a comment of 512 characters.
+	importMaxLineBytes = 64 << 10
+)
+
+var (
+	errImportFormat       = errors.New("format must be one of csv, ndjson")
+	errImportEmpty        = errors.New("no comments to import")
+	errImportTooLarge     = fmt.Errorf("an import must not have more than %d comments", importMaxRows)
+	errImportBodyTooLarge = fmt.Errorf("an import must not be larger than %d MiB", importMaxBytes>>20)
+	errImportLineTooLong  = fmt.Errorf("a line of an import must not be longer than %d KiB", importMaxLineBytes>>10)
+)
+
+// importRow is a comment read from an import file. Other fields, e.g. those of an export, are ignored.
+type importRow struct {
+	Author    string `json:"author"`
+	Comment   string `json:"comment"`
+	ParentID  uint64 `json:"parent_id"`
+	CreatedAt string `json:"created_at"`
+	UpdatedAt string `json:"updated_at"`
+	// Deleted marks a deleted comment of an export, which is skipped.
+	Deleted bool `json:"deleted"`
+
+	// row is the position of the row in the file starting at 1, not counting the CSV header.
+	row int
+	// err is set when the row can not be imported.
+	err error
+}
+
+// ImportComments lets an org admin load comments in bulk from NDJSON or CSV, keeping their timestamps.
+// All rows are validated first and the comments are only saved if every row is valid,
+// otherwise the errors of all rows are reported.
+func (h *handlerImpl) ImportComments(ctx *gin.Context) {
+	org := ctx.Param("org")
+
+	format, err := importFormat(ctx)
+	if err != nil {
+		handlerError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !h.validOrg(ctx, org) {
+		return
+	}
+	login, ok := h.orgAdmin(ctx, org)
+	if !ok {
+		return
+	}
+
+	// the body is read as a whole before any comment is saved, cap its size.
+	body := &countingReader{r: http.MaxBytesReader(ctx.Writer, ctx.Request.Body, importMaxBytes)}
+	var rows []*importRow
+	if format == formatCSV {
+		rows, err = readCSVRows(body)
+	} else {
+		rows, err = readNDJSONRows(body)
+	}
+	if err != nil && body.n >= importMaxBytes {
+		err = errImportBodyTooLarge
+	}
+	if err == errImportTooLarge || err == errImportBodyTooLarge || err == errImportLineTooLong {
+		handlerError(ctx, http.StatusRequestEntityTooLarge, err)
+		return
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to read comments to import for org %v, err: %v", org, err)
+		handlerError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	// deleted comments of an export are not brought back.
+	rows, skipped := skipDeletedRows(rows)
+	if len(rows) == 0 {
+		handlerError(ctx, http.StatusBadRequest, errImportEmpty)
+		return
+	}
+
+	now := time.Now()
+	comments := make([]repository.Comment, len(rows))
+	for i, row := range rows {
+		comments[i], row.err = h.importComment(ctx, org, row, now)
+		if failure, ok := row.err.(validatorFailure); ok {
+			log.Printf("ERROR: failed to validate comments to import for org %v, err: %v", org, failure.error)
+			handlerError(ctx, http.StatusInternalServerError, failure.error)
+			return
+		}
+	}
+	if !h.checkImportAuthors(ctx, org, rows) {
+		return
+	}
+
+	report := &model.ImportReport{Errors: []*model.ImportError{}}
+	for _, row := range rows {
+		if row.err != nil {
+			report.Errors = append(report.Errors, &model.ImportError{Row: row.row, Message: row.err.Error()})
+		}
+	}
+	if len(report.Errors) > 0 {
+		log.Printf("INFO: rejected import of %v comments by %v for org %v with %v invalid rows", len(rows), login, org, len(report.Errors))
+		ctx.JSON(http.StatusUnprocessableEntity, report)
+		return
+	}
+
+	err = h.commentRepo.Import(ctx, comments)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	report.Imported = len(comments)
+	report.Skipped = skipped
+	log.Printf("INFO: imported %v comments by %v for org %v, skipped %v deleted ones", report.Imported, login, org, report.Skipped)
+	ctx.JSON(http.StatusCreated, report)
+}
+
+// skipDeletedRows returns the rows that are not marked as deleted, along with the number of deleted ones.
+func skipDeletedRows(rows []*importRow) ([]*importRow, int) {
+	kept := rows[:0]
+	for _, row := range rows {
+		if !row.Deleted {
+			kept = append(kept, row)
+		}
+	}
+	return kept, len(rows) - len(kept)
+}
+
+// importFormat returns the format of the import file, from the format query parameter or else the Content-Type.
+func importFormat(ctx *gin.Context) (string, error) {
+	if format := ctx.Query("format"); format != "" {
+		if format != formatCSV && format != formatNDJSON {
+			return "", errImportFormat
+		}
+		return format, nil
+	}
+	mediaType, _, _ := mime.ParseMediaType(ctx.GetHeader("Content-Type"))
+	if mediaType == "text/csv" {
+		return formatCSV, nil
+	}
+	return formatNDJSON, nil
+}
+
+// importComment validates given row and returns the comment to save for it.
+func (h *handlerImpl) importComment(ctx *gin.Context, org string, row *importRow, now time.Time) (repository.Comment, error) {
+	c := repository.Comment{
+		Org:     org,
+		Author:  strings.TrimSpace(row.Author),
+		Comment: row.Comment,
+	}
+	if row.err != nil {
+		return c, row.err
+	}
+	if c.Author == "" {
+		return c, errors.New("author is required")
+	}
+	if row.ParentID != 0 {
+		return c, errors.New("replies can not be imported")
+	}
+
+	var err error
+	c.CreatedAt, err = importTime(row.CreatedAt, now)
+	if err != nil {
+		return c, fmt.Errorf("created_at %v", err)
+	}
+	if c.CreatedAt.After(now) {
+		return c, errors.New("created_at must not be in the future")
+	}
+	c.UpdatedAt, err = importTime(row.UpdatedAt, c.CreatedAt)
+	if err != nil {
+		return c, fmt.Errorf("updated_at %v", err)
+	}
+	if c.UpdatedAt.Before(c.CreatedAt) {
+		return c, errors.New("updated_at must not be before created_at")
+	}
+
+	if verr := h.applySecretsPolicy(&c, c.Author); verr != nil {
+		return c, verr
+	}
+	return c, h.validImportComment(ctx, &c)
+}
+
+// validatorFailure is returned for a row a validator could not tell the validity of, e.g. for a blocked
+// pattern of the org that does not compile. It fails the whole import.
+type validatorFailure struct {
+	error
+}
+
+// validImportComment runs the validators of new comments against an imported one, except for the duplicate rule:
+// the rows are not posted by their authors, so that an import can bring back the same text twice.
+func (h *handlerImpl) validImportComment(ctx *gin.Context, c *repository.Comment) error {
+	settings := h.config.ForOrg(c.Org).Validation
+	for _, v := range h.validators {
+		if _, ok := v.(*duplicateValidator); ok {
+			continue
+		}
+		err := v.Validate(ctx, c, settings)
+		if verr, ok := err.(*ValidationError); ok {
+			return verr
+		}
+		if err != nil {
+			return validatorFailure{err}
+		}
+	}
+	return nil
+}
+
+// importTime parses a RFC3339 time of an import row, def if empty.
+func importTime(value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("must be a RFC3339 time")
+	}
+	return t, nil
+}
+
+// checkImportAuthors sets the error of the valid rows whose author is not a public member of the org.
+// The membership of all authors is checked at once.
+func (h *handlerImpl) checkImportAuthors(ctx *gin.Context, org string, rows []*importRow) bool {
+	var authors []string
+	seen := map[string]bool{}
+	for _, row := range rows {
+		author := strings.ToLower(strings.TrimSpace(row.Author))
+		if row.err == nil && !seen[author] {
+			seen[author] = true
+			authors = append(authors, author)
+		}
+	}
+	if len(authors) == 0 {
+		return true
+	}
+
+	members, err := h.github.FilterMembers(ctx, org, authors)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return false
+	}
+	for _, row := range rows {
+		author := strings.TrimSpace(row.Author)
+		if row.err == nil && !members[strings.ToLower(author)] {
+			row.err = fmt.Errorf("author %v is not a member of org %v", author, org)
+		}
+	}
+	return true
+}
+
+// readNDJSONRows reads one comment per non-blank line. A line that is not a JSON object is reported as an invalid row.
+// Lines longer than importMaxLineBytes are not read.
+func readNDJSONRows(r io.Reader) ([]*importRow, error) {
+	var rows []*importRow
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 4096), importMaxLineBytes)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if len(rows) == importMaxRows {
+			return nil, errImportTooLarge
+		}
+		row := &importRow{}
+		if err := json.Unmarshal(line, row); err != nil {
+			row.err = fmt.Errorf("invalid JSON: %v", err)
+		}
+		row.row = len(rows) + 1
+		rows = append(rows, row)
+	}
+	if err := scanner.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			return nil, errImportLineTooLong
+		}
+		return nil, err
+	}
+	return rows, nil
+}
+
+// countingReader counts the bytes read from a reader.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read ...
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// readCSVRows reads one comment per record after a header record naming the columns.
+// The author and comment columns are required, unknown columns are ignored. Values guarded
+// against spreadsheet formulas by an export are restored, see csvCell.
+func readCSVRows(r io.Reader) ([]*importRow, error) {
+	reader := csv.NewReader(r)
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := map[string]int{}
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"author", "comment"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("CSV header must have a %v column", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		if i, ok := columns[name]; ok {
+			return record[i]
+		}
+		return ""
+	}
+
+	var rows []*importRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(rows) == importMaxRows {
+			return nil, errImportTooLarge
+		}
+		row := &importRow{
+			Author:    csvValue(field(record, "author")),
+			Comment:   csvValue(field(record, "comment")),
+			CreatedAt: field(record, "created_at"),
+			UpdatedAt: field(record, "updated_at"),
+			row:       len(rows) + 1,
+		}
+		if deleted := field(record, "is_deleted"); deleted != "" {
+			row.Deleted, err = strconv.ParseBool(deleted)
+			if err != nil {
+				row.err = errors.New("is_deleted must be true or false")
+			}
+		}
+		if parentID := field(record, "parent_id"); parentID != "" {
+			row.ParentID, err = strconv.ParseUint(parentID, 10, 64)
+			if err != nil {
+				row.err = errors.New("parent_id must be a number")
+			}
+		}
+		rows = append(rows, row)
+	}
+}
@@ -0,0 +1,211 @@
+package logic
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/config"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestImportComments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+		config: &config.Config{OrgDefaults: config.OrgSettings{
+			Secrets: config.SecretsRedact,
+			Validation: config.ValidationSettings{
+				BlockedWords:    []string{"darn"},
+				MaxLinks:        1,
+				DuplicateWindow: config.Duration(time.Hour),
+			},
+		}},
+		validators: defaultValidators(commentRepoMock),
+	}
+	createdAt := time.Date(2019, 5, 1, 10, 0, 0, 0, time.UTC)
+	updatedAt := time.Date(2019, 5, 2, 10, 0, 0, 0, time.UTC)
+	importComments := func(url, contentType, body string) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodPost, url, strings.NewReader(body))
+		ctx.Request.Header = http.Header{"Authorization": authHeader["Authorization"], "Content-Type": []string{contentType}}
+		h.ImportComments(ctx)
+		return respWriter
+	}
+	expectAdmin := func() {
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-admin", nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(true, nil).Once()
+	}
+
+	t.Run("happy-path-ndjson", func(t *testing.T) {
+		expectAdmin()
+		githubMock.On("FilterMembers", mock.Anything, "github", []string{"octocat", "hubot"}).
+			Return(map[string]bool{"octocat": true, "hubot": true}, nil).Once()
+		commentRepoMock.On("Import", mock.Anything, []repository.Comment{
+			{Org: "github", Author: "octocat", Comment: "hello", CreatedAt: createdAt, UpdatedAt: updatedAt},
+			{Org: "github", Author: "hubot", Comment: "mail [REDACTED:email]", CreatedAt: createdAt, UpdatedAt: createdAt},
+		}).Return(nil).Once()
+		respWriter := importComments("/orgs/github/comments/import", "application/x-ndjson",
+			`{"id":7,"author":"octocat","comment":"hello","created_at":"2019-05-01T10:00:00Z","updated_at":"2019-05-02T10:00:00Z"}`+"\n\n"+
+				`{"author":"hubot","comment":"mail hu@bot.io","created_at":"2019-05-01T10:00:00Z"}`+"\n")
+		assert.Equal(t, http.StatusCreated, respWriter.Code)
+		assert.Equal(t, `{"imported":2,"errors":[]}`, respWriter.Body.String())
+	})
+
+	t.Run("happy-path-csv", func(t *testing.T) {
+		expectAdmin()
+		githubMock.On("FilterMembers", mock.Anything, "github", []string{"octocat"}).
+			Return(map[string]bool{"octocat": true}, nil).Once()
+		commentRepoMock.On("Import", mock.Anything, []repository.Comment{
+			{Org: "github", Author: "octocat", Comment: "hello, \"world\"", CreatedAt: createdAt, UpdatedAt: updatedAt},
+		}).Return(nil).Once()
+		respWriter := importComments("/orgs/github/comments/import", "text/csv; charset=utf-8",
+			"id,parent_id,author,comment,created_at,updated_at,hidden\n"+
+				"1,,octocat,\"hello, \"\"world\"\"\",2019-05-01T10:00:00Z,2019-05-02T10:00:00Z,false\n")
+		assert.Equal(t, http.StatusCreated, respWriter.Code)
+		assert.Equal(t, `{"imported":1,"errors":[]}`, respWriter.Body.String())
+	})
+
+	t.Run("skip-deleted", func(t *testing.T) {
+		for _, tc := range []struct{ contentType, body string }{
+			{"application/x-ndjson", `{"author":"octocat","comment":"hello","created_at":"2019-05-01T10:00:00Z"}` + "\n" +
+				`{"author":"hubot","comment":"gone","deleted":true}` + "\n"},
+			{"text/csv", "author,comment,created_at,is_deleted\n" +
+				"octocat,hello,2019-05-01T10:00:00Z,false\n" +
+				"hubot,gone,,true\n"},
+		} {
+			expectAdmin()
+			githubMock.On("FilterMembers", mock.Anything, "github", []string{"octocat"}).
+				Return(map[string]bool{"octocat": true}, nil).Once()
+			commentRepoMock.On("Import", mock.Anything, []repository.Comment{
+				{Org: "github", Author: "octocat", Comment: "hello", CreatedAt: createdAt, UpdatedAt: createdAt},
+			}).Return(nil).Once()
+			respWriter := importComments("/orgs/github/comments/import", tc.contentType, tc.body)
+			assert.Equal(t, http.StatusCreated, respWriter.Code, tc.contentType)
+			assert.Equal(t, `{"imported":1,"skipped":1,"errors":[]}`, respWriter.Body.String(), tc.contentType)
+		}
+	})
+
+	t.Run("invalid-rows", func(t *testing.T) {
+		expectAdmin()
+		githubMock.On("FilterMembers", mock.Anything, "github", []string{"octocat", "stranger"}).
+			Return(map[string]bool{"octocat": true}, nil).Once()
+		respWriter := importComments("/orgs/github/comments/import?format=csv", "",
+			"author,comment,parent_id,created_at,updated_at\n"+
+				"octocat,fine,,,\n"+
+				",no author,,,\n"+
+				"octocat,a reply,1,,\n"+
+				"octocat,bad time,,yesterday,\n"+
+				"octocat,time travel,,2019-05-02T10:00:00Z,2019-05-01T10:00:00Z\n"+
+				"octocat,  ,,,\n"+
+				"stranger,hi,,,\n")
+		assert.Equal(t, http.StatusUnprocessableEntity, respWriter.Code)
+		assert.Equal(t, `{"imported":0,"errors":[`+
+			`{"row":2,"message":"author is required"},`+
+			`{"row":3,"message":"replies can not be imported"},`+
+			`{"row":4,"message":"created_at must be a RFC3339 time"},`+
+			`{"row":5,"message":"updated_at must not be before created_at"},`+
+			`{"row":6,"message":"comment must not be empty"},`+
+			`{"row":7,"message":"author stranger is not a member of org github"}]}`, respWriter.Body.String())
+	})
+
+	t.Run("content-rules", func(t *testing.T) {
+		// the duplicate rule is not checked, the same text can be imported twice.
+		expectAdmin()
+		githubMock.On("FilterMembers", mock.Anything, "github", []string{"octocat"}).
+			Return(map[string]bool{"octocat": true}, nil).Once()
+		respWriter := importComments("/orgs/github/comments/import", "",
+			`{"author":"octocat","comment":"darn it"}`+"\n"+
+				`{"author":"octocat","comment":"see http://a.io and http://b.io"}`+"\n"+
+				`{"author":"octocat","comment":"hello"}`+"\n"+
+				`{"author":"octocat","comment":"hello"}`+"\n")
+		assert.Equal(t, http.StatusUnprocessableEntity, respWriter.Code)
+		assert.Equal(t, `{"imported":0,"errors":[`+
+			`{"row":1,"message":"comment contains a blocked word"},`+
+			`{"row":2,"message":"comment must not contain more than 1 links"}]}`, respWriter.Body.String())
+	})
+
+	t.Run("broken-rule", func(t *testing.T) {
+		cfg := h.config
+		defer func() { h.config = cfg }()
+		h.config = &config.Config{OrgDefaults: config.OrgSettings{Validation: config.ValidationSettings{BlockedPatterns: []string{"("}}}}
+		expectAdmin()
+		respWriter := importComments("/orgs/github/comments/import", "", `{"author":"octocat","comment":"hi"}`)
+		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+	})
+
+	t.Run("invalid-json-line", func(t *testing.T) {
+		expectAdmin()
+		respWriter := importComments("/orgs/github/comments/import", "", "{not json}\n")
+		assert.Equal(t, http.StatusUnprocessableEntity, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"row":1,"message":"invalid JSON`)
+	})
+
+	t.Run("line-too-long", func(t *testing.T) {
+		expectAdmin()
+		respWriter := importComments("/orgs/github/comments/import", "",
+			`{"author":"octocat","comment":"`+strings.Repeat("a", importMaxLineBytes)+`"}`+"\n")
+		assert.Equal(t, http.StatusRequestEntityTooLarge, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), "must not be longer than 64 KiB")
+	})
+
+	t.Run("body-too-large", func(t *testing.T) {
+		for _, format := range []string{formatNDJSON, formatCSV} {
+			expectAdmin()
+			respWriter := importComments("/orgs/github/comments/import?format="+format, "",
+				"author,comment\n"+strings.Repeat("\n", importMaxBytes))
+			assert.Equal(t, http.StatusRequestEntityTooLarge, respWriter.Code, format)
+			assert.Contains(t, respWriter.Body.String(), "must not be larger than 32 MiB", format)
+		}
+	})
+
+	t.Run("missing-csv-column", func(t *testing.T) {
+		expectAdmin()
+		respWriter := importComments("/orgs/github/comments/import", "text/csv", "author,text\noctocat,hi\n")
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		expectAdmin()
+		respWriter := importComments("/orgs/github/comments/import", "", "\n")
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	t.Run("not-admin", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(false, nil).Once()
+		respWriter := importComments("/orgs/github/comments/import", "", `{"author":"octocat","comment":"hi"}`)
+		assert.Equal(t, http.StatusForbidden, respWriter.Code)
+	})
+
+	t.Run("invalid-format", func(t *testing.T) {
+		respWriter := importComments("/orgs/github/comments/import?format=md", "", "")
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	t.Run("db-error", func(t *testing.T) {
+		expectAdmin()
+		githubMock.On("FilterMembers", mock.Anything, "github", []string{"octocat"}).
+			Return(map[string]bool{"octocat": true}, nil).Once()
+		commentRepoMock.On("Import", mock.Anything, mock.Anything).Return(errors.New("some repo error")).Once()
+		respWriter := importComments("/orgs/github/comments/import", "", `{"author":"octocat","comment":"hi"}`)
+		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+	})
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
@@ -0,0 +1,116 @@
+package logic
+
+import (
+	"errors"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/model"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+// maxMentions caps the mentions checked against the org per comment, the rest are left as plain text.
+const maxMentions = 10
+
+// mentionPattern matches a @login mention. It is not matched within words, e.g. in e-mail addresses.
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_.@/-])@([A-Za-z0-9](?:-?[A-Za-z0-9]){0,38})`)
+
+// loginPattern matches a Github login: up to 39 letters, digits or single hyphens between them.
+var loginPattern = regexp.MustCompile(`^[A-Za-z0-9](?:-?[A-Za-z0-9]){0,38}$`)
+
+// isLoginChar reports whether given byte may continue a login past a mention match,
+// i.e. whether the match is only a prefix of something that is not a valid login.
+func isLoginChar(b byte) bool {
+	return b == '-' || b == '_' || '0' <= b && b <= '9' || 'A' <= b && b <= 'Z' || 'a' <= b && b <= 'z'
+}
+
+var errInvalidLogin = errors.New("invalid user login")
+
+// parseMentions returns the logins mentioned in given text in lower case, in order of first mention.
+func parseMentions(text string) []string {
+	var logins []string
+	seen := map[string]bool{}
+	for _, m := range mentionPattern.FindAllStringSubmatchIndex(text, -1) {
+		if m[3] < len(text) && isLoginChar(text[m[3]]) {
+			continue
+		}
+		login := strings.ToLower(text[m[2]:m[3]])
+		if seen[login] {
+			continue
+		}
+		seen[login] = true
+		logins = append(logins, login)
+		if len(logins) == maxMentions {
+			break
+		}
+	}
+	return logins
+}
+
+// resolveMentions sets the mentions of a new or edited comment to the logins mentioned in its text
+// that are members of its org, mentions of anybody else are left as plain text.
+// It writes the error response and returns false if the members cannot be checked.
+func (h *handlerImpl) resolveMentions(ctx *gin.Context, c *repository.Comment) bool {
+	c.Mentions = nil
+	logins := parseMentions(c.Comment)
+	if len(logins) == 0 {
+		return true
+	}
+
+	members, err := h.github.FilterMembers(ctx, c.Org, logins)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return false
+	}
+	for _, login := range logins {
+		if members[login] {
+			c.Mentions = append(c.Mentions, login)
+		}
+	}
+	return true
+}
+
+// ListMentions fetches a page of the comments mentioning a user across all orgs, newest first.
+func (h *handlerImpl) ListMentions(ctx *gin.Context) {
+	login := ctx.Param("user")
+	if !loginPattern.MatchString(login) {
+		handlerError(ctx, http.StatusBadRequest, errInvalidLogin)
+		return
+	}
+	login = strings.ToLower(login)
+
+	limit, err := pageLimit(ctx)
+	if err != nil {
+		handlerError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	var after *repository.Cursor
+	if v := ctx.Query("cursor"); v != "" {
+		if after, err = decodeCursor(v); err != nil || after.ID == 0 {
+			handlerError(ctx, http.StatusBadRequest, errInvalidCursor)
+			return
+		}
+	}
+
+	// fetch one extra comment to find out whether there is a next page.
+	comments, err := h.commentRepo.ListMentions(ctx, login, limit+1, after)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := &model.MentionList{}
+	if len(comments) > limit {
+		comments = comments[:limit]
+		last := comments[limit-1]
+		resp.NextCursor = encodeCursor(&repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	resp.Mentions = make([]*model.Mention, len(comments))
+	for i := range comments {
+		resp.Mentions[i] = &model.Mention{Org: comments[i].Org, Comment: *toCommentModel(&comments[i])}
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
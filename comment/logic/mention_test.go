@@ -0,0 +1,213 @@
+package logic
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/config"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestParseMentions(t *testing.T) {
+	cases := map[string][]string{
+		"":                                     nil,
+		"no mentions here":                     nil,
+		"@Alice and @bob, thanks @alice!":      {"alice", "bob"},
+		"cc @awesome-user.":                    {"awesome-user"},
+		"@a_b, @a--b, @a- and @a.b":            {"a"},
+		"@" + strings.Repeat("a", 40):          nil,
+		"@" + strings.Repeat("a", 39):          {strings.Repeat("a", 39)},
+		"mail me at me@example.com":            nil,
+		"see github.com/@octocat or @@octocat": nil,
+		"(@octo-cat)":                          {"octo-cat"},
+	}
+	for text, want := range cases {
+		assert.Equal(t, want, parseMentions(text), text)
+	}
+
+	many := ""
+	for _, login := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k"} {
+		many += "@" + login + " "
+	}
+	assert.Len(t, parseMentions(many), maxMentions)
+}
+
+func TestPostCommentMentions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+		config:      &config.Config{},
+	}
+	post := func(jsonBody string) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		h.PostComment(ctx)
+		return respWriter
+	}
+
+	t.Run("happy-path", func(t *testing.T) {
+		githubMock.On("FilterMembers", mock.Anything, "github", []string{"octocat", "stranger"}).
+			Return(map[string]bool{"octocat": true}, nil).Once()
+		commentRepoMock.On("Save", mock.Anything, mock.MatchedBy(func(c *repository.Comment) bool {
+			return reflect.DeepEqual(c.Mentions, []string{"octocat"})
+		})).Return(nil).Once()
+		respWriter := post(`{"comment":"hey @OctoCat and @stranger","mentions":["forged"]}`)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"mentions":["octocat"]`)
+	})
+
+	t.Run("no-mentions", func(t *testing.T) {
+		commentRepoMock.On("Save", mock.Anything, mock.MatchedBy(func(c *repository.Comment) bool {
+			return c.Mentions == nil
+		})).Return(nil).Once()
+		respWriter := post(`{"comment":"ping me@home"}`)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.NotContains(t, respWriter.Body.String(), `"mentions"`)
+	})
+
+	t.Run("github-err", func(t *testing.T) {
+		githubMock.On("FilterMembers", mock.Anything, "github", []string{"octocat"}).
+			Return(nil, errors.New("some github error")).Once()
+		respWriter := post(`{"comment":"hey @octocat"}`)
+		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+	})
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
+
+func TestUpdateCommentMentions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+		config:      &config.Config{},
+	}
+	edit := func(jsonBody string) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: editHeader}
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).
+			Return(&repository.Comment{ID: 1, Author: "awesome-user", Mentions: []string{"hubot"}, Version: 1}, nil).Once()
+		h.UpdateComment(ctx)
+		return respWriter
+	}
+
+	t.Run("added", func(t *testing.T) {
+		githubMock.On("FilterMembers", mock.Anything, "github", []string{"octocat"}).
+			Return(map[string]bool{"octocat": true}, nil).Once()
+		commentRepoMock.On("Update", mock.Anything, mock.MatchedBy(func(c *repository.Comment) bool {
+			return reflect.DeepEqual(c.Mentions, []string{"octocat"})
+		})).Return(nil).Once()
+		respWriter := edit(`{"comment":"hey @octocat"}`)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"mentions":["octocat"]`)
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		commentRepoMock.On("Update", mock.Anything, mock.MatchedBy(func(c *repository.Comment) bool {
+			return c.Mentions == nil
+		})).Return(nil).Once()
+		respWriter := edit(`{"comment":"never mind"}`)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.NotContains(t, respWriter.Body.String(), `"mentions"`)
+	})
+
+	t.Run("github-err", func(t *testing.T) {
+		githubMock.On("FilterMembers", mock.Anything, "github", []string{"octocat"}).
+			Return(nil, errors.New("some github error")).Once()
+		respWriter := edit(`{"comment":"hey @octocat"}`)
+		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+	})
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
+
+func TestListMentions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		commentRepo: commentRepoMock,
+	}
+	list := func(user, query string) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "user", Value: user}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/users/"+user+"/mentions"+query, nil)
+		h.ListMentions(ctx)
+		return respWriter
+	}
+	now := time.Now().UTC()
+	comments := []repository.Comment{
+		{ID: 3, Org: "github", Comment: "hey @octocat", Mentions: []string{"octocat"}, CreatedAt: now},
+		{ID: 2, Org: "golang", Comment: "@octocat ping", Mentions: []string{"octocat"}, CreatedAt: now.Add(-time.Minute)},
+	}
+
+	t.Run("happy-path", func(t *testing.T) {
+		commentRepoMock.On("ListMentions", mock.Anything, "octocat", 3, (*repository.Cursor)(nil)).Return(comments, nil).Once()
+		respWriter := list("OctoCat", "?limit=2")
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `{"mentions":[{"org":"github","id":3,`)
+		assert.Contains(t, respWriter.Body.String(), `"org":"golang","id":2,`)
+		assert.NotContains(t, respWriter.Body.String(), `"next_cursor"`)
+	})
+
+	t.Run("next-page", func(t *testing.T) {
+		commentRepoMock.On("ListMentions", mock.Anything, "octocat", 2, (*repository.Cursor)(nil)).Return(comments, nil).Once()
+		respWriter := list("octocat", "?limit=1")
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		next := encodeCursor(&repository.Cursor{CreatedAt: now, ID: 3})
+		assert.Contains(t, respWriter.Body.String(), `"next_cursor":"`+next+`"`)
+
+		commentRepoMock.On("ListMentions", mock.Anything, "octocat", 2, &repository.Cursor{CreatedAt: now, ID: 3}).
+			Return(comments[1:], nil).Once()
+		respWriter = list("octocat", "?limit=1&cursor="+next)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"id":2,`)
+	})
+
+	t.Run("invalid-request", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, list("-octocat", "").Code)
+		assert.Equal(t, http.StatusBadRequest, list("octo.cat", "").Code)
+		assert.Equal(t, http.StatusBadRequest, list("octo_cat", "").Code)
+		assert.Equal(t, http.StatusBadRequest, list("octo--cat", "").Code)
+		assert.Equal(t, http.StatusBadRequest, list("octocat", "?limit=0").Code)
+		assert.Equal(t, http.StatusBadRequest, list("octocat", "?cursor=junk").Code)
+		assert.Equal(t, http.StatusBadRequest, list("octocat", "?cursor="+encodeCursor(&repository.Cursor{Offset: 5})).Code)
+	})
+
+	t.Run("repo-err", func(t *testing.T) {
+		commentRepoMock.On("ListMentions", mock.Anything, "octocat", 51, (*repository.Cursor)(nil)).
+			Return([]repository.Comment(nil), errors.New("some repo error")).Once()
+		respWriter := list("octocat", "")
+		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+	})
+
+	commentRepoMock.AssertExpectations(t)
+}
@@ -0,0 +1,223 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/model"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+// maxFlagReasonLength is the length of the reason column of comment_flags.
+const maxFlagReasonLength = 256
+
+var errInvalidReason = errors.New("reason must be between 1 and 256 characters")
+
+// flagRequest is the request body of FlagComment.
+type flagRequest struct {
+	Reason string `json:"reason"`
+}
+
+// visibleComment writes the not found response for a comment hidden by moderation, unless the caller is an
+// admin of the org. Others can not tell a hidden comment from a missing one.
+func (h *handlerImpl) visibleComment(ctx *gin.Context, org string, c *repository.Comment) bool {
+	if !c.IsHidden {
+		return true
+	}
+	if bearerToken(ctx) != "" {
+		if _, ok := h.currentUser(ctx); !ok {
+			return false
+		}
+		isAdmin, ok := h.isOrgAdmin(ctx, org)
+		if !ok {
+			return false
+		}
+		if isAdmin {
+			return true
+		}
+	}
+	handlerError(ctx, http.StatusNotFound, repository.ErrNotFound)
+	return false
+}
+
+// FlagComment lets a member of the org report a comment for review by a moderator.
+// The comment is hidden once its flags go above the flag threshold of the org.
+func (h *handlerImpl) FlagComment(ctx *gin.Context) {
+	org := ctx.Param("org")
+	id, ok := commentID(ctx)
+	if !ok {
+		return
+	}
+
+	login, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	data, ok := readPostBody(ctx)
+	if !ok {
+		return
+	}
+	req := &flagRequest{}
+	err := json.Unmarshal(data, req)
+	if err != nil {
+		log.Printf("ERROR: failed to unmarshal request body, err: %v", err)
+		handlerError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Reason == "" || utf8.RuneCountInString(req.Reason) > maxFlagReasonLength {
+		handlerError(ctx, http.StatusBadRequest, errInvalidReason)
+		return
+	}
+
+	isValid, err := h.github.IsMember(ctx, org, login)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if !isValid {
+		log.Printf("INFO: user %v is not a member of org %v", login, org)
+		handlerError(ctx, http.StatusNotFound, errors.New("user is not a member of specified org"))
+		return
+	}
+
+	f := &repository.Flag{CommentID: id, Flagger: login, Reason: req.Reason}
+	c, err := h.commentRepo.Flag(ctx, org, f, h.config.ForOrg(org).FlagThreshold)
+	if err == repository.ErrNotFound {
+		handlerError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if err == repository.ErrAlreadyFlagged {
+		handlerError(ctx, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if c.IsHidden {
+		log.Printf("INFO: comment %v of org %v is hidden with %v pending flags", c.ID, org, c.FlagCount)
+	}
+
+	ctx.JSON(http.StatusOK, toFlagModel(f))
+}
+
+// ModerationQueue lets an org admin fetch a page of flagged comments along with their pending flags.
+func (h *handlerImpl) ModerationQueue(ctx *gin.Context) {
+	org := ctx.Param("org")
+
+	opts := repository.ListOptions{Flagged: true}
+	var err error
+	if opts.Limit, err = pageLimit(ctx); err != nil {
+		handlerError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if v := ctx.Query("cursor"); v != "" {
+		if opts.After, err = decodeCursor(v); err != nil {
+			handlerError(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	if !h.validOrg(ctx, org) {
+		return
+	}
+	if _, ok := h.orgAdmin(ctx, org); !ok {
+		return
+	}
+
+	// fetch one extra comment to find out whether there is a next page.
+	limit := opts.Limit
+	opts.Limit++
+	comments, err := h.commentRepo.ListAll(ctx, org, opts)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := &model.ModerationQueue{}
+	if len(comments) > limit {
+		comments = comments[:limit]
+		last := comments[limit-1]
+		resp.NextCursor = encodeCursor(&repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	ids := make([]uint64, len(comments))
+	byID := make(map[uint64]*model.ModerationItem, len(comments))
+	resp.Items = make([]*model.ModerationItem, len(comments))
+	for i := range comments {
+		item := &model.ModerationItem{
+			Comment:   *toCommentModel(&comments[i]),
+			FlagCount: comments[i].FlagCount,
+			Flags:     []*model.Flag{},
+		}
+		ids[i] = item.ID
+		byID[item.ID] = item
+		resp.Items[i] = item
+	}
+
+	flags, err := h.commentRepo.ListFlags(ctx, ids)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	for i := range flags {
+		if item, ok := byID[flags[i].CommentID]; ok {
+			item.Flags = append(item.Flags, toFlagModel(&flags[i]))
+		}
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// ApproveComment lets an org admin dismiss the pending flags of a comment and show it again.
+func (h *handlerImpl) ApproveComment(ctx *gin.Context) {
+	h.reviewComment(ctx, false)
+}
+
+// HideComment lets an org admin resolve the pending flags of a comment by hiding it.
+func (h *handlerImpl) HideComment(ctx *gin.Context) {
+	h.reviewComment(ctx, true)
+}
+
+// reviewComment resolves the pending flags of a comment by either hiding or showing it.
+func (h *handlerImpl) reviewComment(ctx *gin.Context, hide bool) {
+	org := ctx.Param("org")
+	id, ok := commentID(ctx)
+	if !ok || !h.validOrg(ctx, org) {
+		return
+	}
+
+	login, ok := h.orgAdmin(ctx, org)
+	if !ok {
+		return
+	}
+
+	c, err := h.commentRepo.Review(ctx, org, id, hide, login)
+	if err == repository.ErrNotFound {
+		handlerError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	log.Printf("INFO: user %v reviewed comment %v of org %v, hidden: %v", login, id, org, hide)
+
+	ctx.JSON(http.StatusOK, toCommentModel(c))
+}
+
+// toFlagModel converts the storage object into the API model.
+func toFlagModel(f *repository.Flag) *model.Flag {
+	return &model.Flag{
+		Flagger:   f.Flagger,
+		Reason:    f.Reason,
+		CreatedAt: f.CreatedAt,
+	}
+}
@@ -0,0 +1,205 @@
+package logic
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/config"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFlagComment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+		config: &config.Config{
+			OrgDefaults: config.OrgSettings{FlagThreshold: 3},
+			Orgs:        map[string]config.OrgSettings{"github": {FlagThreshold: 5}},
+		},
+	}
+	newContext := func(jsonBody string) (*gin.Context, *httptest.ResponseRecorder) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
+		return ctx, respWriter
+	}
+
+	t.Run("happy-path", func(t *testing.T) {
+		ctx, respWriter := newContext(`{"reason":" spam "}`)
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("Flag", mock.Anything, "github", mock.MatchedBy(func(f *repository.Flag) bool {
+			return f.CommentID == 1 && f.Flagger == "awesome-user" && f.Reason == "spam"
+		}), 5).Return(&repository.Comment{ID: 1, FlagCount: 5, IsHidden: true}, nil).Once()
+		h.FlagComment(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"reason":"spam"`)
+	})
+
+	t.Run("missing-reason", func(t *testing.T) {
+		ctx, respWriter := newContext(`{"reason":"  "}`)
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		h.FlagComment(ctx)
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	t.Run("body-too-large", func(t *testing.T) {
+		ctx, respWriter := newContext(`{"reason":"` + strings.Repeat("x", maxPostBodyBytes) + `"}`)
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		h.FlagComment(ctx)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, respWriter.Code)
+	})
+
+	t.Run("not-member", func(t *testing.T) {
+		ctx, respWriter := newContext(`{"reason":"spam"}`)
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(false, nil).Once()
+		h.FlagComment(ctx)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+
+	t.Run("already-flagged", func(t *testing.T) {
+		ctx, respWriter := newContext(`{"reason":"spam"}`)
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("Flag", mock.Anything, "github", mock.Anything, 5).Return(nil, repository.ErrAlreadyFlagged).Once()
+		h.FlagComment(ctx)
+		assert.Equal(t, http.StatusConflict, respWriter.Code)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		ctx, respWriter := newContext(`{"reason":"spam"}`)
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("Flag", mock.Anything, "github", mock.Anything, 5).Return(nil, repository.ErrNotFound).Once()
+		h.FlagComment(ctx)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+}
+
+func TestModerationQueue(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+
+	t.Run("happy-path", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/moderation/queue?limit=1", nil)
+		ctx.Request.Header = authHeader
+
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-admin", nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(true, nil).Once()
+		commentRepoMock.On("ListAll", mock.Anything, "github", repository.ListOptions{Limit: 2, Flagged: true}).
+			Return([]repository.Comment{{ID: 1, FlagCount: 2, IsHidden: true}, {ID: 2, FlagCount: 1}}, nil).Once()
+		commentRepoMock.On("ListFlags", mock.Anything, []uint64{1}).
+			Return([]repository.Flag{{CommentID: 1, Flagger: "a", Reason: "spam"}, {CommentID: 1, Flagger: "b", Reason: "rude"}}, nil).Once()
+		h.ModerationQueue(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"hidden":true`)
+		assert.Contains(t, respWriter.Body.String(), `"flag_count":2`)
+		assert.Contains(t, respWriter.Body.String(), `"reason":"rude"`)
+		assert.Contains(t, respWriter.Body.String(), `"next_cursor"`)
+	})
+
+	t.Run("not-admin", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/moderation/queue", nil)
+		ctx.Request.Header = authHeader
+
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(false, nil).Once()
+		h.ModerationQueue(ctx)
+		assert.Equal(t, http.StatusForbidden, respWriter.Code)
+	})
+
+	t.Run("invalid-cursor", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/moderation/queue?cursor=bogus", nil)
+
+		h.ModerationQueue(ctx)
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+}
+
+func TestReviewComment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+	newContext := func() (*gin.Context, *httptest.ResponseRecorder) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		ctx.Request = &http.Request{Header: authHeader}
+
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-admin", nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(true, nil).Once()
+		return ctx, respWriter
+	}
+
+	t.Run("approve", func(t *testing.T) {
+		ctx, respWriter := newContext()
+		commentRepoMock.On("Review", mock.Anything, "github", uint64(1), false, "awesome-admin").Return(&repository.Comment{ID: 1}, nil).Once()
+		h.ApproveComment(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.NotContains(t, respWriter.Body.String(), `"hidden"`)
+	})
+
+	t.Run("hide", func(t *testing.T) {
+		ctx, respWriter := newContext()
+		commentRepoMock.On("Review", mock.Anything, "github", uint64(1), true, "awesome-admin").Return(&repository.Comment{ID: 1, IsHidden: true}, nil).Once()
+		h.HideComment(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"hidden":true`)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		ctx, respWriter := newContext()
+		commentRepoMock.On("Review", mock.Anything, "github", uint64(1), true, "awesome-admin").Return(nil, repository.ErrNotFound).Once()
+		h.HideComment(ctx)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+
+	t.Run("repo-err", func(t *testing.T) {
+		ctx, respWriter := newContext()
+		commentRepoMock.On("Review", mock.Anything, "github", uint64(1), false, "awesome-admin").Return(nil, errors.New("some repo error")).Once()
+		h.ApproveComment(ctx)
+		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+	})
+}
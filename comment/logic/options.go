@@ -0,0 +1,133 @@
+package logic
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 100
+
+	sortCreatedAt     = "created_at"
+	sortCreatedAtDesc = "-created_at"
+	sortTop           = repository.RankTop
+	sortHot           = repository.RankHot
+)
+
+var (
+	errInvalidLimit  = errors.New("limit must be a number between 1 and 100")
+	errInvalidCursor = errors.New("invalid cursor")
+	errInvalidSort   = errors.New("sort must be one of created_at, -created_at, top, hot")
+	errInvalidRange  = errors.New("since must be before until")
+)
+
+// listOptions builds the repository list options from the filter, sort, limit and cursor query params.
+func listOptions(ctx *gin.Context) (repository.ListOptions, error) {
+	opts := repository.ListOptions{
+		Author: ctx.Query("author"),
+	}
+
+	var err error
+	if v := ctx.Query("tag"); v != "" {
+		if opts.Tag, err = normalizeTag(v); err != nil {
+			return opts, err
+		}
+	}
+	if opts.Limit, err = pageLimit(ctx); err != nil {
+		return opts, err
+	}
+	if opts.Since, err = timeQuery(ctx, "since"); err != nil {
+		return opts, err
+	}
+	if opts.Until, err = timeQuery(ctx, "until"); err != nil {
+		return opts, err
+	}
+	if !opts.Since.IsZero() && !opts.Until.IsZero() && !opts.Since.Before(opts.Until) {
+		return opts, errInvalidRange
+	}
+
+	switch ctx.DefaultQuery("sort", sortCreatedAt) {
+	case sortCreatedAt:
+	case sortCreatedAtDesc:
+		opts.Descending = true
+	case sortTop, sortHot:
+		opts.Rank = ctx.Query("sort")
+	default:
+		return opts, errInvalidSort
+	}
+
+	if v := ctx.Query("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err != nil {
+			return opts, err
+		}
+		switch {
+		case opts.Rank == "" && cursor.ID != 0:
+			opts.After = cursor
+		case opts.Rank == sortTop && cursor.Offset > 0:
+			opts.Offset = cursor.Offset
+		case opts.Rank == sortHot && cursor.Offset > 0 && cursor.RankedAt != nil:
+			opts.Offset = cursor.Offset
+			opts.RankedAt = *cursor.RankedAt
+		default:
+			return opts, errInvalidCursor
+		}
+	}
+	if opts.Rank == sortHot && opts.RankedAt.IsZero() {
+		opts.RankedAt = time.Now().UTC().Truncate(time.Second)
+	}
+	return opts, nil
+}
+
+// nextCursor returns the cursor of the page following the page of given limit ending with last.
+func nextCursor(opts repository.ListOptions, limit int, last *repository.Comment) string {
+	if opts.Rank == "" {
+		return encodeCursor(&repository.Cursor{CreatedAt: last.CreatedAt, ID: last.ID})
+	}
+
+	next := &repository.Cursor{Offset: opts.Offset + limit}
+	if opts.Rank == sortHot {
+		next.RankedAt = &opts.RankedAt
+	}
+	return encodeCursor(next)
+}
+
+// pageLimit parses the limit query param.
+func pageLimit(ctx *gin.Context) (int, error) {
+	v := ctx.Query("limit")
+	if v == "" {
+		return defaultPageLimit, nil
+	}
+
+	limit, err := strconv.Atoi(v)
+	if err != nil || limit < 1 || limit > maxPageLimit {
+		return 0, errInvalidLimit
+	}
+	return limit, nil
+}
+
+// encodeCursor returns the opaque representation of the cursor.
+func encodeCursor(c *repository.Cursor) string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor returned by encodeCursor.
+func decodeCursor(s string) (*repository.Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errInvalidCursor
+	}
+	c := &repository.Cursor{}
+	if err := json.Unmarshal(data, c); err != nil || (c.ID == 0 && c.Offset <= 0) {
+		return nil, errInvalidCursor
+	}
+	return c, nil
+}
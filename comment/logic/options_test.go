@@ -0,0 +1,80 @@
+package logic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursor(t *testing.T) {
+	t.Run("round-trip", func(t *testing.T) {
+		c := &repository.Cursor{CreatedAt: time.Date(2020, 2, 22, 13, 12, 0, 640310000, time.UTC), ID: 42}
+		decoded, err := decodeCursor(encodeCursor(c))
+		assert.Nil(t, err)
+		assert.Equal(t, c.ID, decoded.ID)
+		assert.True(t, c.CreatedAt.Equal(decoded.CreatedAt))
+	})
+
+	t.Run("not-base64", func(t *testing.T) {
+		_, err := decodeCursor("not a cursor!")
+		assert.Equal(t, errInvalidCursor, err)
+	})
+
+	t.Run("not-json", func(t *testing.T) {
+		_, err := decodeCursor("bm90IGpzb24")
+		assert.Equal(t, errInvalidCursor, err)
+	})
+}
+
+func TestListOptions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newContext := func(target string) *gin.Context {
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = httptest.NewRequest(http.MethodGet, target, nil)
+		return ctx
+	}
+
+	t.Run("defaults", func(t *testing.T) {
+		opts, err := listOptions(newContext("/orgs/github/comments"))
+		assert.Nil(t, err)
+		assert.Equal(t, repository.ListOptions{Limit: defaultPageLimit}, opts)
+	})
+
+	t.Run("filters-and-sort", func(t *testing.T) {
+		opts, err := listOptions(newContext("/orgs/github/comments?author=awesome-user&since=2020-02-21T00:00:00Z&until=2020-02-22T00:00:00Z&sort=-created_at"))
+		assert.Nil(t, err)
+		assert.Equal(t, "awesome-user", opts.Author)
+		assert.Equal(t, time.Date(2020, 2, 21, 0, 0, 0, 0, time.UTC), opts.Since)
+		assert.Equal(t, time.Date(2020, 2, 22, 0, 0, 0, 0, time.UTC), opts.Until)
+		assert.True(t, opts.Descending)
+	})
+
+	t.Run("hot", func(t *testing.T) {
+		before := time.Now().Add(-time.Second)
+		opts, err := listOptions(newContext("/orgs/github/comments?sort=hot"))
+		assert.Nil(t, err)
+		assert.Equal(t, repository.RankHot, opts.Rank)
+		assert.True(t, opts.RankedAt.After(before))
+	})
+
+	t.Run("bad-sort", func(t *testing.T) {
+		_, err := listOptions(newContext("/orgs/github/comments?sort=author"))
+		assert.Equal(t, errInvalidSort, err)
+	})
+
+	t.Run("bad-since", func(t *testing.T) {
+		_, err := listOptions(newContext("/orgs/github/comments?since=24h"))
+		assert.NotNil(t, err)
+	})
+
+	t.Run("empty-range", func(t *testing.T) {
+		_, err := listOptions(newContext("/orgs/github/comments?since=2020-02-22T00:00:00Z&until=2020-02-21T00:00:00Z"))
+		assert.Equal(t, errInvalidRange, err)
+	})
+}
@@ -0,0 +1,81 @@
+package logic
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+var errPinReply = errors.New("only top level comments can be pinned")
+
+// PinComment lets an org admin pin a top level comment to the top of the comments of the org.
+func (h *handlerImpl) PinComment(ctx *gin.Context) {
+	h.pinComment(ctx, true)
+}
+
+// UnpinComment lets an org admin unpin a pinned comment.
+func (h *handlerImpl) UnpinComment(ctx *gin.Context) {
+	h.pinComment(ctx, false)
+}
+
+// pinComment either pins or unpins a comment. Pinning a pinned comment, or unpinning
+// an unpinned one, returns the comment as it is.
+func (h *handlerImpl) pinComment(ctx *gin.Context, pin bool) {
+	org := ctx.Param("org")
+	id, ok := commentID(ctx)
+	if !ok || !h.validOrg(ctx, org) {
+		return
+	}
+
+	login, ok := h.orgAdmin(ctx, org)
+	if !ok {
+		return
+	}
+
+	existing, err := h.commentRepo.Get(ctx, org, id)
+	if err == repository.ErrNotFound {
+		handlerError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if pin == !existing.PinnedAt.IsZero() {
+		respondComment(ctx, http.StatusOK, existing)
+		return
+	}
+	if pin && existing.ParentID != 0 {
+		handlerError(ctx, http.StatusUnprocessableEntity, errPinReply)
+		return
+	}
+
+	var c *repository.Comment
+	if pin {
+		c, err = h.commentRepo.Pin(ctx, org, id, h.config.ForOrg(org).MaxPinned)
+	} else {
+		c, err = h.commentRepo.Unpin(ctx, org, id)
+	}
+	if err == repository.ErrNotFound {
+		handlerError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if err == repository.ErrPinLimit {
+		handlerError(ctx, http.StatusConflict, err)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	log.Printf("INFO: user %v changed comment %v of org %v, pinned: %v", login, id, org, pin)
+
+	// pinning does not change the tags, mentions and reactions of the comment.
+	c.Tags = existing.Tags
+	c.Mentions = existing.Mentions
+	c.Reactions = existing.Reactions
+	respondComment(ctx, http.StatusOK, c)
+}
@@ -0,0 +1,134 @@
+package logic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/config"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPinComment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+		config: &config.Config{
+			OrgDefaults: config.OrgSettings{MaxPinned: 3},
+			Orgs:        map[string]config.OrgSettings{"github": {MaxPinned: 2}},
+		},
+	}
+	pinnedAt := time.Date(2020, 2, 21, 13, 0, 0, 0, time.UTC)
+	newContext := func(admin bool) (*gin.Context, *httptest.ResponseRecorder) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		ctx.Request = &http.Request{Header: authHeader}
+
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-admin", nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(admin, nil).Once()
+		return ctx, respWriter
+	}
+
+	t.Run("pin", func(t *testing.T) {
+		ctx, respWriter := newContext(true)
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).
+			Return(&repository.Comment{ID: 1, Version: 1, Tags: []string{"q3"}}, nil).Once()
+		commentRepoMock.On("Pin", mock.Anything, "github", uint64(1), 2).
+			Return(&repository.Comment{ID: 1, Version: 2, PinnedAt: pinnedAt}, nil).Once()
+		h.PinComment(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"tags":["q3"]`)
+		assert.Contains(t, respWriter.Body.String(), `"pinned":true`)
+		assert.Regexp(t, `^W/"2-[0-9a-f]{16}"$`, respWriter.Header().Get("ETag"))
+	})
+
+	t.Run("already-pinned", func(t *testing.T) {
+		ctx, respWriter := newContext(true)
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).
+			Return(&repository.Comment{ID: 1, Version: 2, PinnedAt: pinnedAt}, nil).Once()
+		h.PinComment(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"pinned":true`)
+	})
+
+	t.Run("pin-reply", func(t *testing.T) {
+		ctx, respWriter := newContext(true)
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).
+			Return(&repository.Comment{ID: 1, ParentID: 7}, nil).Once()
+		h.PinComment(ctx)
+		assert.Equal(t, http.StatusUnprocessableEntity, respWriter.Code)
+	})
+
+	t.Run("pin-limit", func(t *testing.T) {
+		ctx, respWriter := newContext(true)
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1}, nil).Once()
+		commentRepoMock.On("Pin", mock.Anything, "github", uint64(1), 2).Return(nil, repository.ErrPinLimit).Once()
+		h.PinComment(ctx)
+		assert.Equal(t, http.StatusConflict, respWriter.Code)
+	})
+
+	t.Run("unpin", func(t *testing.T) {
+		ctx, respWriter := newContext(true)
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).
+			Return(&repository.Comment{ID: 1, Version: 2, PinnedAt: pinnedAt}, nil).Once()
+		commentRepoMock.On("Unpin", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1, Version: 3}, nil).Once()
+		h.UnpinComment(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.NotContains(t, respWriter.Body.String(), `"pinned"`)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		ctx, respWriter := newContext(true)
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(nil, repository.ErrNotFound).Once()
+		h.UnpinComment(ctx)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+
+	t.Run("not-admin", func(t *testing.T) {
+		ctx, respWriter := newContext(false)
+		h.PinComment(ctx)
+		assert.Equal(t, http.StatusForbidden, respWriter.Code)
+	})
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
+
+func TestListPinnedComments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+	pinnedAt := time.Date(2020, 2, 21, 13, 0, 0, 0, time.UTC)
+
+	respWriter := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(respWriter)
+	ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+	ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments?limit=2", nil)
+
+	githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+	commentRepoMock.On("ListAll", mock.Anything, "github", repository.ListOptions{Limit: 3, PinnedFirst: true}).
+		Return([]repository.Comment{{ID: 9, PinnedAt: pinnedAt}, {ID: 1}, {ID: 2}, {ID: 3}}, nil).Once()
+	h.ListAllComments(ctx)
+	assert.Equal(t, http.StatusOK, respWriter.Code)
+	assert.Contains(t, respWriter.Body.String(), `{"comments":[{"id":9,`)
+	assert.Contains(t, respWriter.Body.String(), `"pinned":true`)
+	assert.Contains(t, respWriter.Body.String(), `"next_cursor":"`+encodeCursor(&repository.Cursor{ID: 2})+`"`)
+	assert.NotContains(t, respWriter.Body.String(), `"id":3`)
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
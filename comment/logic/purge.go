@@ -0,0 +1,75 @@
+package logic
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/model"
+)
+
+// maxPurgeBatches bounds the batches of a single purge run, so that a large backlog of expired comments
+// neither holds the purge lock nor keeps the DB busy for long. The rest is left to the next run.
+const maxPurgeBatches = 100
+
+// PurgeComments lets an admin permanently delete all soft-deleted comments past the retention window.
+func (h *handlerImpl) PurgeComments(ctx *gin.Context) {
+	if !h.isAdmin(ctx) {
+		return
+	}
+
+	purged, err := h.purgeExpired(ctx)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	ctx.JSON(http.StatusOK, &model.Purge{Purged: purged})
+}
+
+// RunPurger purges expired soft-deleted comments and idempotency keys every purge interval until ctx is done.
+func (h *handlerImpl) RunPurger(ctx context.Context) {
+	ticker := time.NewTicker(h.config.PurgeInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_, _ = h.purgeExpired(ctx)
+			_, _ = h.commentRepo.PurgeIdempotencyKeys(ctx, time.Now())
+		}
+	}
+}
+
+// purgeExpired permanently deletes comments soft-deleted longer than the retention window ago,
+// one bounded batch at a time, and returns how many were purged. Batches go on until nothing is
+// left to purge, since purging replies can make their deleted parents purgeable, but stop early
+// after maxPurgeBatches or once ctx is done.
+func (h *handlerImpl) purgeExpired(ctx context.Context) (int, error) {
+	h.purgeMu.Lock()
+	defer h.purgeMu.Unlock()
+
+	deletedBefore := time.Now().Add(-h.config.Retention)
+	total := 0
+	for batch := 0; batch < maxPurgeBatches; batch++ {
+		if err := ctx.Err(); err != nil {
+			log.Printf("ERROR: purge stopped after %v comments deleted before %v, err: %v", total, deletedBefore, err)
+			return total, err
+		}
+		purged, err := h.commentRepo.Purge(ctx, deletedBefore, h.config.PurgeBatchSize)
+		total += purged
+		if err != nil {
+			log.Printf("ERROR: purge stopped after %v comments deleted before %v, err: %v", total, deletedBefore, err)
+			return total, err
+		}
+		if purged == 0 {
+			break
+		}
+	}
+
+	log.Printf("INFO: purged %v comments deleted before %v", total, deletedBefore)
+	return total, nil
+}
@@ -0,0 +1,116 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/config"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPurgeComments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		commentRepo: commentRepoMock,
+		config: &config.Config{
+			Retention:      24 * time.Hour,
+			PurgeBatchSize: 2,
+			AdminToken:     "secret",
+		},
+	}
+
+	t.Run("happy-path", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/admin/purge", nil)
+		ctx.Request.Header.Set("X-Admin-Token", "secret")
+
+		before := mock.MatchedBy(func(t time.Time) bool { return time.Since(t) >= 24*time.Hour })
+		commentRepoMock.On("Purge", mock.Anything, before, 2).Return(2, nil).Once()
+		commentRepoMock.On("Purge", mock.Anything, before, 2).Return(1, nil).Once()
+		commentRepoMock.On("Purge", mock.Anything, before, 2).Return(0, nil).Once()
+		h.PurgeComments(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"purged":3`)
+	})
+
+	t.Run("thread", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/admin/purge", nil)
+		ctx.Request.Header.Set("X-Admin-Token", "secret")
+
+		// a deleted comment is only purged once its deleted replies are, in a later batch
+		// which has to run even though the batch of the replies was not full.
+		before := mock.MatchedBy(func(t time.Time) bool { return time.Since(t) >= 24*time.Hour })
+		commentRepoMock.On("Purge", mock.Anything, before, 2).Return(1, nil).Once()
+		commentRepoMock.On("Purge", mock.Anything, before, 2).Return(1, nil).Once()
+		commentRepoMock.On("Purge", mock.Anything, before, 2).Return(0, nil).Once()
+		h.PurgeComments(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"purged":2`)
+	})
+
+	t.Run("batch-limit", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/admin/purge", nil)
+		ctx.Request.Header.Set("X-Admin-Token", "secret")
+
+		commentRepoMock.On("Purge", mock.Anything, mock.Anything, 2).Return(2, nil).Times(maxPurgeBatches)
+		h.PurgeComments(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), fmt.Sprintf(`"purged":%v`, 2*maxPurgeBatches))
+	})
+
+	t.Run("canceled", func(t *testing.T) {
+		cancelCtx, cancel := context.WithCancel(context.Background())
+		commentRepoMock.On("Purge", mock.Anything, mock.Anything, 2).Return(2, nil).
+			Run(func(mock.Arguments) { cancel() }).Once()
+		purged, err := h.purgeExpired(cancelCtx)
+		assert.Equal(t, context.Canceled, err)
+		assert.Equal(t, 2, purged)
+	})
+
+	t.Run("invalid-token", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/admin/purge", nil)
+		ctx.Request.Header.Set("X-Admin-Token", "guess")
+
+		h.PurgeComments(ctx)
+		assert.Equal(t, http.StatusForbidden, respWriter.Code)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/admin/purge", nil)
+
+		h := &handlerImpl{config: &config.Config{}}
+		h.PurgeComments(ctx)
+		assert.Equal(t, http.StatusForbidden, respWriter.Code)
+	})
+
+	t.Run("repo-err", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Request = httptest.NewRequest(http.MethodPost, "/admin/purge", nil)
+		ctx.Request.Header.Set("X-Admin-Token", "secret")
+
+		commentRepoMock.On("Purge", mock.Anything, mock.Anything, 2).Return(0, errors.New("some repo error")).Once()
+		h.PurgeComments(ctx)
+		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+	})
+
+	commentRepoMock.AssertExpectations(t)
+}
@@ -0,0 +1,104 @@
+package logic
+
+import (
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/ratelimit"
+)
+
+var (
+	errRateLimited = errors.New("too many comments, please retry later")
+	errSlowMode    = errors.New("slow mode is on, please wait before commenting again")
+)
+
+// LimitPosts is a middleware limiting how often comments can be posted per author and per org.
+// It authenticates the caller and aborts with 429 once a limit is exceeded. Posts rejected
+// as invalid do not count against the limits.
+func (h *handlerImpl) LimitPosts(ctx *gin.Context) {
+	login, ok := h.currentUser(ctx)
+	if !ok {
+		ctx.Abort()
+		return
+	}
+	author := strings.ToLower(login)
+	org := strings.ToLower(ctx.Param("org"))
+
+	// slow mode is checked first as it is the cheapest limit to hit, the tokens taken
+	// before a denied bucket are given back.
+	if res := h.slowMode.Allow(author + "/" + org); !res.Allowed {
+		log.Printf("INFO: user %v is in slow mode for org %v", login, org)
+		setRetryAfter(ctx, res)
+		handlerError(ctx, http.StatusTooManyRequests, errSlowMode)
+		ctx.Abort()
+		return
+	}
+
+	authorRes := h.authorLimiter.Allow(author)
+	if !authorRes.Allowed {
+		log.Printf("INFO: user %v exceeded the author rate limit", login)
+		h.slowMode.Refund(author + "/" + org)
+		rateLimited(ctx, authorRes)
+		return
+	}
+
+	orgRes := h.orgLimiter.Allow(org)
+	if !orgRes.Allowed {
+		log.Printf("INFO: org %v exceeded the org rate limit", org)
+		h.slowMode.Refund(author + "/" + org)
+		h.authorLimiter.Refund(author)
+		rateLimited(ctx, orgRes)
+		return
+	}
+
+	// report the limit closest to being exceeded.
+	res := authorRes
+	if orgRes.Limit > 0 && (res.Limit == 0 || orgRes.Remaining < res.Remaining) {
+		res = orgRes
+	}
+	setRateLimitHeaders(ctx, res)
+	ctx.Next()
+
+	if rejectedPost(ctx.Writer.Status()) {
+		h.slowMode.Refund(author + "/" + org)
+		h.authorLimiter.Refund(author)
+		h.orgLimiter.Refund(org)
+	}
+}
+
+// rejectedPost tells whether given status rejects a post as invalid.
+func rejectedPost(status int) bool {
+	switch status {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return true
+	}
+	return false
+}
+
+// rateLimited writes the 429 response for a denied bucket and aborts the request.
+func rateLimited(ctx *gin.Context, res ratelimit.Result) {
+	setRateLimitHeaders(ctx, res)
+	setRetryAfter(ctx, res)
+	handlerError(ctx, http.StatusTooManyRequests, errRateLimited)
+	ctx.Abort()
+}
+
+// setRateLimitHeaders sets the X-RateLimit-* headers, which are left out for disabled limits.
+func setRateLimitHeaders(ctx *gin.Context, res ratelimit.Result) {
+	if res.Limit == 0 {
+		return
+	}
+	ctx.Header("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+	ctx.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+	ctx.Header("X-RateLimit-Reset", strconv.FormatInt(int64(math.Ceil(float64(res.Reset.UnixNano())/1e9)), 10))
+}
+
+// setRetryAfter sets the Retry-After header in whole seconds.
+func setRetryAfter(ctx *gin.Context, res ratelimit.Result) {
+	ctx.Header("Retry-After", strconv.Itoa(int(math.Ceil(res.RetryAfter.Seconds()))))
+}
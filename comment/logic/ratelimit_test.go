@@ -0,0 +1,110 @@
+package logic
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/ratelimit"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestLimitPosts(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	newHandler := func(authorLimit, orgLimit int, slowMode time.Duration) *handlerImpl {
+		return &handlerImpl{
+			github:        githubMock,
+			authorLimiter: ratelimit.New(authorLimit, time.Minute),
+			orgLimiter:    ratelimit.New(orgLimit, time.Minute),
+			slowMode:      ratelimit.New(1, slowMode),
+		}
+	}
+	post := func(h *handlerImpl, org, login string) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: org}}
+		ctx.Request = &http.Request{Header: authHeader}
+
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return(login, nil).Once()
+		h.LimitPosts(ctx)
+		assert.Equal(t, ctx.IsAborted(), respWriter.Code != http.StatusOK)
+		return respWriter
+	}
+
+	t.Run("happy-path", func(t *testing.T) {
+		h := newHandler(2, 5, 0)
+		respWriter := post(h, "github", "awesome-user")
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Equal(t, "2", respWriter.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "1", respWriter.Header().Get("X-RateLimit-Remaining"))
+		assert.NotEmpty(t, respWriter.Header().Get("X-RateLimit-Reset"))
+	})
+
+	t.Run("author-limit", func(t *testing.T) {
+		h := newHandler(1, 5, 0)
+		post(h, "github", "awesome-user")
+		respWriter := post(h, "golang", "Awesome-User")
+		assert.Equal(t, http.StatusTooManyRequests, respWriter.Code)
+		assert.Equal(t, "60", respWriter.Header().Get("Retry-After"))
+		assert.Equal(t, "0", respWriter.Header().Get("X-RateLimit-Remaining"))
+	})
+
+	t.Run("org-limit", func(t *testing.T) {
+		h := newHandler(5, 2, 0)
+		post(h, "github", "awesome-user")
+		respWriter := post(h, "github", "other-user")
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Equal(t, "2", respWriter.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, "0", respWriter.Header().Get("X-RateLimit-Remaining"))
+
+		respWriter = post(h, "github", "third-user")
+		assert.Equal(t, http.StatusTooManyRequests, respWriter.Code)
+		assert.Equal(t, "30", respWriter.Header().Get("Retry-After"))
+	})
+
+	t.Run("slow-mode", func(t *testing.T) {
+		h := newHandler(5, 5, 10*time.Second)
+		post(h, "github", "awesome-user")
+		respWriter := post(h, "github", "awesome-user")
+		assert.Equal(t, http.StatusTooManyRequests, respWriter.Code)
+		assert.Equal(t, "10", respWriter.Header().Get("Retry-After"))
+		assert.Contains(t, respWriter.Body.String(), "slow mode")
+
+		respWriter = post(h, "golang", "awesome-user")
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+	})
+
+	t.Run("missing-token", func(t *testing.T) {
+		h := newHandler(5, 5, 0)
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = &http.Request{Header: http.Header{}}
+
+		h.LimitPosts(ctx)
+		assert.Equal(t, http.StatusUnauthorized, respWriter.Code)
+		assert.True(t, ctx.IsAborted())
+	})
+
+	t.Run("rejected-post", func(t *testing.T) {
+		h := newHandler(1, 5, 10*time.Second)
+		router := gin.New()
+		router.POST("/orgs/:org/comments", h.LimitPosts, func(ctx *gin.Context) {
+			handlerError(ctx, http.StatusBadRequest, errInvalidView)
+		})
+		for i := 0; i < 2; i++ {
+			githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+			respWriter := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodPost, "/orgs/github/comments", nil)
+			req.Header = authHeader
+			router.ServeHTTP(respWriter, req)
+			assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+		}
+		assert.Equal(t, http.StatusOK, post(h, "github", "awesome-user").Code)
+	})
+}
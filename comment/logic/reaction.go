@@ -0,0 +1,123 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+// reactionContents are the reactions Github knows of.
+var reactionContents = []string{"+1", "-1", "laugh", "confused", "heart", "hooray", "rocket", "eyes"}
+
+var (
+	errInvalidReaction  = errors.New("content must be one of " + strings.Join(reactionContents, ", "))
+	errReactionNotFound = errors.New("reaction not found")
+)
+
+// reactionRequest is the request body of ReactToComment.
+type reactionRequest struct {
+	Content string `json:"content"`
+}
+
+// validReaction tells whether given content is one of reactionContents.
+func validReaction(content string) bool {
+	for _, c := range reactionContents {
+		if c == content {
+			return true
+		}
+	}
+	return false
+}
+
+// ReactToComment lets a member of the org react to a comment, once with every kind of reaction.
+func (h *handlerImpl) ReactToComment(ctx *gin.Context) {
+	org := ctx.Param("org")
+	id, ok := commentID(ctx)
+	if !ok {
+		return
+	}
+
+	login, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	data, ok := readPostBody(ctx)
+	if !ok {
+		return
+	}
+	req := &reactionRequest{}
+	err := json.Unmarshal(data, req)
+	if err != nil {
+		log.Printf("ERROR: failed to unmarshal request body, err: %v", err)
+		handlerError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if !validReaction(req.Content) {
+		handlerError(ctx, http.StatusBadRequest, errInvalidReaction)
+		return
+	}
+
+	isValid, err := h.github.IsMember(ctx, org, login)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if !isValid {
+		log.Printf("INFO: user %v is not a member of org %v", login, org)
+		handlerError(ctx, http.StatusNotFound, errors.New("user is not a member of specified org"))
+		return
+	}
+
+	created, err := h.commentRepo.React(ctx, org, &repository.Reaction{CommentID: id, Reactor: login, Content: req.Content})
+	if err == repository.ErrNotFound {
+		handlerError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	h.writeComment(ctx, org, id, status)
+}
+
+// RemoveReaction lets a user take back their reaction to a comment, given by the content query param.
+func (h *handlerImpl) RemoveReaction(ctx *gin.Context) {
+	org := ctx.Param("org")
+	id, ok := commentID(ctx)
+	if !ok {
+		return
+	}
+
+	login, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	content := ctx.Query("content")
+	if !validReaction(content) {
+		handlerError(ctx, http.StatusBadRequest, errInvalidReaction)
+		return
+	}
+
+	err := h.commentRepo.Unreact(ctx, org, &repository.Reaction{CommentID: id, Reactor: login, Content: content})
+	if err == repository.ErrNotFound {
+		handlerError(ctx, http.StatusNotFound, errReactionNotFound)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	h.writeComment(ctx, org, id, http.StatusOK)
+}
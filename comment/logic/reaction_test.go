@@ -0,0 +1,134 @@
+package logic
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReactToComment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+	react := func(jsonBody string) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		h.ReactToComment(ctx)
+		return respWriter
+	}
+	heart := &repository.Reaction{CommentID: 1, Reactor: "awesome-user", Content: "heart"}
+
+	t.Run("happy-path", func(t *testing.T) {
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("React", mock.Anything, "github", heart).Return(true, nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).
+			Return(&repository.Comment{ID: 1, Version: 2, Reactions: map[string]int{"+1": 2, "heart": 1}}, nil).Once()
+		respWriter := react(`{"content":"heart"}`)
+		assert.Equal(t, http.StatusCreated, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"reactions":{"+1":2,"heart":1}`)
+		assert.Regexp(t, `^W/"2-[0-9a-f]{16}"$`, respWriter.Header().Get("ETag"))
+	})
+
+	t.Run("same-reaction-again", func(t *testing.T) {
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("React", mock.Anything, "github", heart).Return(false, nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).
+			Return(&repository.Comment{ID: 1, Reactions: map[string]int{"heart": 1}}, nil).Once()
+		respWriter := react(`{"content":"heart"}`)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+	})
+
+	t.Run("invalid-content", func(t *testing.T) {
+		respWriter := react(`{"content":"thumbsup"}`)
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	t.Run("body-too-large", func(t *testing.T) {
+		respWriter := react(`{"content":"heart","pad":"` + strings.Repeat("x", maxPostBodyBytes) + `"}`)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, respWriter.Code)
+	})
+
+	t.Run("not-a-member", func(t *testing.T) {
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(false, nil).Once()
+		respWriter := react(`{"content":"heart"}`)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+
+	t.Run("comment-not-found", func(t *testing.T) {
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("React", mock.Anything, "github", heart).Return(false, repository.ErrNotFound).Once()
+		respWriter := react(`{"content":"heart"}`)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+
+	t.Run("repo-err", func(t *testing.T) {
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("React", mock.Anything, "github", heart).Return(false, errors.New("some repo error")).Once()
+		respWriter := react(`{"content":"heart"}`)
+		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+	})
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
+
+func TestRemoveReaction(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+	unreact := func(content string) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		ctx.Request = httptest.NewRequest(http.MethodDelete, "/orgs/github/comments/1/reactions?content="+content, nil)
+		ctx.Request.Header = authHeader
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		h.RemoveReaction(ctx)
+		return respWriter
+	}
+	plusOne := &repository.Reaction{CommentID: 1, Reactor: "awesome-user", Content: "+1"}
+
+	t.Run("happy-path", func(t *testing.T) {
+		commentRepoMock.On("Unreact", mock.Anything, "github", plusOne).Return(nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1}, nil).Once()
+		respWriter := unreact("%2B1")
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.NotContains(t, respWriter.Body.String(), `"reactions"`)
+	})
+
+	t.Run("no-such-reaction", func(t *testing.T) {
+		commentRepoMock.On("Unreact", mock.Anything, "github", plusOne).Return(repository.ErrNotFound).Once()
+		respWriter := unreact("%2B1")
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+
+	t.Run("invalid-content", func(t *testing.T) {
+		respWriter := unreact("")
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
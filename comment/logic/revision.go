@@ -0,0 +1,48 @@
+package logic
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/model"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+// ListRevisions fetches every prior text of a comment of an org, oldest first.
+func (h *handlerImpl) ListRevisions(ctx *gin.Context) {
+	org := ctx.Param("org")
+	id, ok := commentID(ctx)
+	if !ok || !h.validOrg(ctx, org) {
+		return
+	}
+
+	c, err := h.commentRepo.Get(ctx, org, id)
+	if err == repository.ErrNotFound {
+		handlerError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if !h.visibleComment(ctx, org, c) {
+		return
+	}
+
+	revisions, err := h.commentRepo.ListRevisions(ctx, id)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := &model.RevisionList{Revisions: make([]*model.Revision, len(revisions))}
+	for i, r := range revisions {
+		resp.Revisions[i] = &model.Revision{
+			Comment:    r.Comment,
+			CreatedAt:  r.CreatedAt,
+			ReplacedAt: r.ReplacedAt,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
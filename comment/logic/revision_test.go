@@ -0,0 +1,60 @@
+package logic
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListRevisions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+
+	t.Run("happy-path", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1}, nil).Once()
+		commentRepoMock.On("ListRevisions", mock.Anything, uint64(1)).Return([]repository.Revision{{CommentID: 1, Comment: "first text"}}, nil).Once()
+		h.ListRevisions(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"comment":"first text"`)
+	})
+
+	t.Run("not-found", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(nil, repository.ErrNotFound).Once()
+		h.ListRevisions(ctx)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+
+	t.Run("repo-err", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1}, nil).Once()
+		commentRepoMock.On("ListRevisions", mock.Anything, uint64(1)).Return([]repository.Revision{}, errors.New("some repo error")).Once()
+		h.ListRevisions(ctx)
+		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+	})
+}
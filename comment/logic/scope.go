@@ -0,0 +1,76 @@
+package logic
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+// repoNamePattern matches the names Github allows for repositories.
+var repoNamePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,100}$`)
+
+var (
+	errInvalidRepo   = errors.New("invalid repository name")
+	errInvalidIssue  = errors.New("issue number must be a positive number")
+	errRepoNotFound  = errors.New("repository not found in specified org")
+	errIssueNotFound = errors.New("issue not found in specified repository")
+)
+
+// parseScope returns the scope of the request from the repo and number path params.
+// Repository names are case insensitive on Github and kept in lower case.
+func parseScope(ctx *gin.Context) (repository.Scope, error) {
+	scope := repository.Scope{}
+	repo := ctx.Param("repo")
+	if repo == "" {
+		return scope, nil
+	}
+	if !repoNamePattern.MatchString(repo) {
+		return scope, errInvalidRepo
+	}
+	scope.Repo = strings.ToLower(repo)
+
+	if number := ctx.Param("number"); number != "" {
+		n, err := strconv.Atoi(number)
+		if err != nil || n <= 0 {
+			return scope, errInvalidIssue
+		}
+		scope.Issue = n
+	}
+	return scope, nil
+}
+
+// validScope checks on Github that the repository, and the issue, of given scope exist in the org
+// and writes the error response otherwise. Org wide scopes need no check.
+func (h *handlerImpl) validScope(ctx *gin.Context, org string, scope repository.Scope) bool {
+	if scope.Repo == "" {
+		return true
+	}
+
+	var isValid bool
+	var err error
+	if scope.Issue != 0 {
+		isValid, err = h.github.IsValidIssue(ctx, org, scope.Repo, scope.Issue)
+	} else {
+		isValid, err = h.github.IsValidRepo(ctx, org, scope.Repo)
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return false
+	}
+	if !isValid {
+		log.Printf("INFO: scope %+v does not exist in org %v", scope, org)
+		if scope.Issue != 0 {
+			handlerError(ctx, http.StatusNotFound, errIssueNotFound)
+		} else {
+			handlerError(ctx, http.StatusNotFound, errRepoNotFound)
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,105 @@
+package logic
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/config"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestScopedComments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+		config:      &config.Config{},
+	}
+	post := func(params ...gin.Param) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = append([]gin.Param{gin.Param{Key: "org", Value: "github"}}, params...)
+		body := ioutil.NopCloser(bytes.NewReader([]byte(`{"comment":"test comment"}`)))
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
+		h.PostComment(ctx)
+		return respWriter
+	}
+	list := func(params ...gin.Param) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = append([]gin.Param{gin.Param{Key: "org", Value: "github"}}, params...)
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments", nil)
+		h.ListAllComments(ctx)
+		return respWriter
+	}
+	repo := gin.Param{Key: "repo", Value: "Hub"}
+	issue := gin.Param{Key: "number", Value: "42"}
+
+	t.Run("post-to-issue", func(t *testing.T) {
+		githubMock.On("IsValidIssue", mock.Anything, "github", "hub", 42).Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("Save", mock.Anything, mock.MatchedBy(func(c *repository.Comment) bool {
+			return c.Org == "github" && c.Repo == "hub" && c.Issue == 42
+		})).Return(nil).Once()
+		respWriter := post(repo, issue)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"repo":"hub","issue":42`)
+	})
+
+	t.Run("post-to-missing-repo", func(t *testing.T) {
+		githubMock.On("IsValidRepo", mock.Anything, "github", "hub").Return(false, nil).Once()
+		respWriter := post(repo)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+
+	t.Run("post-to-missing-issue", func(t *testing.T) {
+		githubMock.On("IsValidIssue", mock.Anything, "github", "hub", 42).Return(false, nil).Once()
+		respWriter := post(repo, issue)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+
+	t.Run("invalid-scope", func(t *testing.T) {
+		respWriter := post(gin.Param{Key: "repo", Value: "hub?x=1"})
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+		respWriter = post(repo, gin.Param{Key: "number", Value: "-1"})
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	t.Run("list-repo-with-roll-up", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		githubMock.On("IsValidRepo", mock.Anything, "github", "hub").Return(true, nil).Once()
+		commentRepoMock.On("ListAll", mock.Anything, "github", repository.ListOptions{
+			Limit:       defaultPageLimit + 1,
+			PinnedFirst: true,
+			Scope:       repository.Scope{Repo: "hub"},
+		}).Return([]repository.Comment{{ID: 1, Repo: "hub"}, {ID: 2, Repo: "hub", Issue: 42}}, nil).Once()
+		respWriter := list(repo)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"id":2,"repo":"hub","issue":42`)
+	})
+
+	t.Run("list-issue", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		githubMock.On("IsValidIssue", mock.Anything, "github", "hub", 42).Return(true, nil).Once()
+		commentRepoMock.On("ListAll", mock.Anything, "github", repository.ListOptions{
+			Limit:       defaultPageLimit + 1,
+			PinnedFirst: true,
+			Scope:       repository.Scope{Repo: "hub", Issue: 42},
+		}).Return([]repository.Comment{}, nil).Once()
+		respWriter := list(repo, issue)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+	})
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
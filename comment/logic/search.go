@@ -0,0 +1,71 @@
+package logic
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/model"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+var errEmptyQuery = errors.New("q must not be empty")
+
+// SearchComments runs a full-text search over the comments of an org.
+func (h *handlerImpl) SearchComments(ctx *gin.Context) {
+	org := ctx.Param("org")
+
+	opts, err := searchOptions(ctx)
+	if err != nil {
+		handlerError(ctx, http.StatusBadRequest, err)
+		return
+	}
+
+	if !h.validOrg(ctx, org) {
+		return
+	}
+
+	results, err := h.commentRepo.Search(ctx, org, opts)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := &model.SearchResultList{Results: make([]*model.SearchResult, len(results))}
+	for i := range results {
+		resp.Results[i] = &model.SearchResult{
+			Comment: *toCommentModel(&results[i].Comment),
+			Rank:    results[i].Rank,
+			Snippet: results[i].Snippet,
+		}
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// searchOptions builds the repository search options from the query params.
+func searchOptions(ctx *gin.Context) (repository.SearchOptions, error) {
+	opts := repository.SearchOptions{
+		Query:  strings.TrimSpace(ctx.Query("q")),
+		Author: ctx.Query("author"),
+	}
+	if opts.Query == "" {
+		return opts, errEmptyQuery
+	}
+
+	var err error
+	if opts.Limit, err = pageLimit(ctx); err != nil {
+		return opts, err
+	}
+	if opts.Since, err = timeQuery(ctx, "since"); err != nil {
+		return opts, err
+	}
+	if opts.Until, err = timeQuery(ctx, "until"); err != nil {
+		return opts, err
+	}
+	if !opts.Since.IsZero() && !opts.Until.IsZero() && !opts.Since.Before(opts.Until) {
+		return opts, errInvalidRange
+	}
+	return opts, nil
+}
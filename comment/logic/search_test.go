@@ -0,0 +1,83 @@
+package logic
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSearchComments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+
+	t.Run("happy-path", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments/search?q=deploy&author=awesome-user&since=2020-02-01T00:00:00Z", nil)
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		commentRepoMock.On("Search", mock.Anything, "github", mock.MatchedBy(func(opts repository.SearchOptions) bool {
+			return opts.Query == "deploy" && opts.Author == "awesome-user" && !opts.Since.IsZero() && opts.Until.IsZero()
+		})).Return([]repository.SearchResult{{Comment: repository.Comment{ID: 1}, Rank: 0.5, Snippet: "<mark>deploy</mark>"}}, nil).Once()
+		h.SearchComments(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"id":1`)
+		assert.Contains(t, respWriter.Body.String(), `"rank":0.5`)
+	})
+
+	t.Run("empty-query", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments/search?q=", nil)
+
+		h.SearchComments(ctx)
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	t.Run("bad-date", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments/search?q=deploy&until=yesterday", nil)
+
+		h.SearchComments(ctx)
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	t.Run("bad-range", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments/search?q=deploy&since=2020-02-02T00:00:00Z&until=2020-02-01T00:00:00Z", nil)
+
+		h.SearchComments(ctx)
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), errInvalidRange.Error())
+	})
+
+	t.Run("repo-err", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments/search?q=deploy", nil)
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		commentRepoMock.On("Search", mock.Anything, mock.Anything, mock.Anything).Return([]repository.SearchResult{}, errors.New("some repo error")).Once()
+		h.SearchComments(ctx)
+		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+	})
+}
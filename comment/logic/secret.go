@@ -0,0 +1,65 @@
+package logic
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/config"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/comment/secret"
+)
+
+// ruleSecrets is reported in 422 responses for comments rejected for containing secrets.
+const ruleSecrets = "secrets"
+
+// scanSecrets looks for secrets and emails in the text of given comment and, depending on the
+// setting of its org, redacts them in place or writes the error response. The secrets are never logged.
+func (h *handlerImpl) scanSecrets(ctx *gin.Context, c *repository.Comment) bool {
+	login := ctx.GetString(loginKey)
+	if verr := h.applySecretsPolicy(c, login); verr != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, verr)
+		return false
+	}
+	return true
+}
+
+// applySecretsPolicy redacts the secrets found in given comment of given author in place,
+// or returns the validation error if its org rejects them or if the redacted comment is too long.
+func (h *handlerImpl) applySecretsPolicy(c *repository.Comment, login string) *ValidationError {
+	policy := h.config.ForOrg(c.Org).Secrets
+	if policy == config.SecretsAllow {
+		return nil
+	}
+
+	findings := secret.Scan(c.Comment)
+	if len(findings) == 0 {
+		return nil
+	}
+	kinds := strings.Join(secret.Kinds(findings), ", ")
+
+	if policy == config.SecretsReject {
+		log.Printf("INFO: rejected comment by %v for org %v containing %v secrets: %v", login, c.Org, len(findings), kinds)
+		return &ValidationError{
+			Rule:    ruleSecrets,
+			Message: fmt.Sprintf("comment must not contain secrets, found: %v", kinds),
+		}
+	}
+
+	redacted := secret.Redact(c.Comment, findings)
+	// the redaction markers can push a comment of valid length over the limit, tell it apart from the length rule.
+	max := h.config.ForOrg(c.Org).Validation.CommentLength()
+	if utf8.RuneCountInString(c.Comment) <= max && utf8.RuneCountInString(redacted) > max {
+		log.Printf("INFO: rejected comment by %v for org %v too long once its %v secrets are redacted: %v", login, c.Org, len(findings), kinds)
+		return &ValidationError{
+			Rule:    ruleSecrets,
+			Message: fmt.Sprintf("comment must not be longer than %v characters once its secrets are redacted", max),
+		}
+	}
+	c.Comment = redacted
+	log.Printf("INFO: redacted %v secrets from comment by %v for org %v: %v", len(findings), login, c.Org, kinds)
+	return nil
+}
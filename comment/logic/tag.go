@@ -0,0 +1,103 @@
+package logic
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/config"
+	"github.com/rahulbharuka/github-proxy/comment/model"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+// ruleTags is the name of the tag rule, as reported in 422 responses.
+const ruleTags = "tags"
+
+// tagPattern matches a normalized tag, e.g. incident or q3.
+var tagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,31}$`)
+
+var errInvalidTag = errors.New("tag must be 1 to 32 letters, digits, '_', '.' or '-' starting with a letter or digit")
+
+// normalizeTag returns given tag in lower case, or an error if it is not a valid tag.
+func normalizeTag(tag string) (string, error) {
+	tag = strings.ToLower(strings.TrimSpace(tag))
+	if !tagPattern.MatchString(tag) {
+		return "", errInvalidTag
+	}
+	return tag, nil
+}
+
+// normalizeTags normalizes and dedupes given tags of a comment and checks them against the tag
+// settings of its org. It returns the *ValidationError for the first broken rule.
+func normalizeTags(tags []string, settings config.TagSettings) ([]string, *ValidationError) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	if settings.MaxTags == 0 {
+		return nil, &ValidationError{Rule: ruleTags, Message: "tags are disabled for this org"}
+	}
+
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		t, err := normalizeTag(tag)
+		if err != nil {
+			return nil, &ValidationError{Rule: ruleTags, Message: fmt.Sprintf("invalid tag %q: %v", tag, err)}
+		}
+		if !seen[t] {
+			seen[t] = true
+			normalized = append(normalized, t)
+		}
+	}
+	if len(normalized) > settings.MaxTags {
+		return nil, &ValidationError{Rule: ruleTags, Message: fmt.Sprintf("a comment can have at most %d tags", settings.MaxTags)}
+	}
+
+	if len(settings.Allowed) > 0 {
+		allowed := make(map[string]bool, len(settings.Allowed))
+		for _, tag := range settings.Allowed {
+			allowed[strings.ToLower(tag)] = true
+		}
+		for _, tag := range normalized {
+			if !allowed[tag] {
+				return nil, &ValidationError{Rule: ruleTags, Message: fmt.Sprintf("tag %q is not allowed in this org", tag)}
+			}
+		}
+	}
+	return normalized, nil
+}
+
+// validTags normalizes the tags of given comment and writes the error response if they break
+// the tag settings of its org.
+func (h *handlerImpl) validTags(ctx *gin.Context, c *repository.Comment) bool {
+	tags, verr := normalizeTags(c.Tags, h.config.ForOrg(c.Org).Tags)
+	if verr != nil {
+		ctx.JSON(http.StatusUnprocessableEntity, verr)
+		return false
+	}
+	c.Tags = tags
+	return true
+}
+
+// ListTags fetches the tags used by the active comments of an org along with their usage counts.
+func (h *handlerImpl) ListTags(ctx *gin.Context) {
+	org := ctx.Param("org")
+	if !h.validOrg(ctx, org) {
+		return
+	}
+
+	counts, err := h.commentRepo.TagCounts(ctx, org)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	resp := &model.TagList{Tags: make([]*model.TagCount, len(counts))}
+	for i, c := range counts {
+		resp.Tags[i] = &model.TagCount{Tag: c.Tag, Count: c.Count}
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
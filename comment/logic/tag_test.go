@@ -0,0 +1,139 @@
+package logic
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/config"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTags(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+		config: &config.Config{
+			OrgDefaults: config.OrgSettings{Tags: config.TagSettings{MaxTags: 2}},
+			Orgs: map[string]config.OrgSettings{
+				"github": {Tags: config.TagSettings{MaxTags: 3, Allowed: []string{"Incident", "q3", "deploy"}}},
+				"golang": {},
+			},
+		},
+	}
+	post := func(org, jsonBody string) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: org}}
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		h.PostComment(ctx)
+		return respWriter
+	}
+
+	t.Run("post-with-tags", func(t *testing.T) {
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("Save", mock.Anything, mock.MatchedBy(func(c *repository.Comment) bool {
+			return reflect.DeepEqual(c.Tags, []string{"incident", "q3"})
+		})).Return(nil).Once()
+		respWriter := post("github", `{"comment":"test comment","tags":[" INCIDENT","q3","incident"]}`)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"tags":["incident","q3"]`)
+	})
+
+	t.Run("free-form-tags", func(t *testing.T) {
+		githubMock.On("IsMember", mock.Anything, "rust-lang", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("Save", mock.Anything, mock.MatchedBy(func(c *repository.Comment) bool {
+			return reflect.DeepEqual(c.Tags, []string{"anything.goes"})
+		})).Return(nil).Once()
+		respWriter := post("rust-lang", `{"comment":"test comment","tags":["anything.goes"]}`)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+	})
+
+	t.Run("invalid-tags", func(t *testing.T) {
+		cases := map[string]string{
+			"malformed":   `{"comment":"test comment","tags":["on call"]}`,
+			"not-allowed": `{"comment":"test comment","tags":["q4"]}`,
+			"too-many":    `{"comment":"test comment","tags":["incident","q3","deploy"]}`,
+		}
+		for name, jsonBody := range cases {
+			org := "github"
+			if name == "too-many" {
+				org = "rust-lang"
+			}
+			respWriter := post(org, jsonBody)
+			assert.Equal(t, http.StatusUnprocessableEntity, respWriter.Code, name)
+			assert.Contains(t, respWriter.Body.String(), `"rule":"tags"`, name)
+		}
+	})
+
+	t.Run("tags-disabled", func(t *testing.T) {
+		respWriter := post("golang", `{"comment":"test comment","tags":["q3"]}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), "tags are disabled")
+	})
+
+	t.Run("filter-by-tag", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments?tag=Q3", nil)
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		commentRepoMock.On("ListAll", mock.Anything, "github", repository.ListOptions{
+			Limit:       defaultPageLimit + 1,
+			Tag:         "q3",
+			PinnedFirst: true,
+		}).Return([]repository.Comment{{ID: 1, Tags: []string{"q3"}}}, nil).Once()
+		h.ListAllComments(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"tags":["q3"]`)
+	})
+
+	t.Run("filter-by-invalid-tag", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments?tag=-q3", nil)
+		h.ListAllComments(ctx)
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	listTags := func() *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		h.ListTags(ctx)
+		return respWriter
+	}
+
+	t.Run("list-tags", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		commentRepoMock.On("TagCounts", mock.Anything, "github").
+			Return([]repository.TagCount{{Tag: "incident", Count: 3}, {Tag: "q3", Count: 1}}, nil).Once()
+		respWriter := listTags()
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Equal(t, `{"tags":[{"tag":"incident","count":3},{"tag":"q3","count":1}]}`, respWriter.Body.String())
+	})
+
+	t.Run("list-tags-repo-error", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		commentRepoMock.On("TagCounts", mock.Anything, "github").Return([]repository.TagCount(nil), errors.New("some repo error")).Once()
+		respWriter := listTags()
+		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+	})
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
@@ -0,0 +1,112 @@
+package logic
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/model"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+const (
+	viewFlat   = "flat"
+	viewThread = "thread"
+)
+
+var errInvalidView = errors.New("view must be one of flat, thread")
+
+// PostReply posts a reply to a comment of the org.
+func (h *handlerImpl) PostReply(ctx *gin.Context) {
+	org := ctx.Param("org")
+	id, ok := commentID(ctx)
+	if !ok {
+		return
+	}
+
+	parent, err := h.commentRepo.Get(ctx, org, id)
+	// replies to a hidden comment would be hidden along with it.
+	if err == nil && parent.IsHidden {
+		err = repository.ErrNotFound
+	}
+	if err == repository.ErrNotFound {
+		handlerError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	// replies stay in the scope of the comment they reply to.
+	h.postComment(ctx, repository.Scope{Repo: parent.Repo, Issue: parent.Issue}, parent.ID)
+}
+
+// attachReplies loads the replies of given top level comments and nests them under their parents.
+// The text of the replies is in given format, see toFormattedModel.
+func (h *handlerImpl) attachReplies(ctx context.Context, org string, roots []*model.Comment, format string) error {
+	ids := make([]uint64, len(roots))
+	for i, root := range roots {
+		ids[i] = root.ID
+	}
+
+	replies, err := h.commentRepo.ListReplies(ctx, org, ids)
+	if err != nil {
+		return err
+	}
+
+	buildThreads(roots, replies, format)
+	return nil
+}
+
+// buildThreads nests replies under their parents and sets the reply count of every comment.
+// Replies are expected in creation order so that every parent is seen before its replies.
+// Soft-deleted replies are kept as tombstones only when an active reply is beneath them.
+func buildThreads(roots []*model.Comment, replies []repository.Comment, format string) {
+	// walk back from the newest reply, so that every reply is seen before its parent.
+	kept := make(map[uint64]bool, len(replies))
+	for i := len(replies) - 1; i >= 0; i-- {
+		if !replies[i].IsDeleted || kept[replies[i].ID] {
+			kept[replies[i].ID] = true
+			kept[replies[i].ParentID] = true
+		}
+	}
+
+	byID := make(map[uint64]*model.Comment, len(roots)+len(replies))
+	for _, root := range roots {
+		byID[root.ID] = root
+	}
+	for i := range replies {
+		if !kept[replies[i].ID] {
+			continue
+		}
+		reply := toThreadModel(&replies[i], format)
+		byID[reply.ID] = reply
+		if parent, ok := byID[reply.ParentID]; ok {
+			parent.Replies = append(parent.Replies, reply)
+		}
+	}
+
+	for _, c := range byID {
+		count := len(c.Replies)
+		c.ReplyCount = &count
+	}
+}
+
+// toThreadModel converts the storage object into the API model of the thread view. A soft-deleted
+// comment is only kept as a tombstone holding the place of its replies, see model.Comment.Deleted.
+func toThreadModel(c *repository.Comment, format string) *model.Comment {
+	if !c.IsDeleted {
+		return toFormattedModel(c, format)
+	}
+	return &model.Comment{
+		ID:        c.ID,
+		Repo:      c.Repo,
+		Issue:     c.Issue,
+		ParentID:  c.ParentID,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+		Deleted:   true,
+	}
+}
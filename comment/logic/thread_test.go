@@ -0,0 +1,177 @@
+package logic
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/config"
+	"github.com/rahulbharuka/github-proxy/comment/model"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPostReply(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+		config:      &config.Config{},
+	}
+
+	t.Run("happy-path", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "7"}}
+		jsonBody := `{"author":"awesome-user","comment":"test reply"}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
+
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(7)).Return(&repository.Comment{ID: 7, Repo: "hub", Issue: 42}, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("Save", mock.Anything, mock.MatchedBy(func(c *repository.Comment) bool {
+			return c.ParentID == 7 && c.Org == "github" && c.Repo == "hub" && c.Issue == 42
+		})).Return(nil).Once()
+		h.PostReply(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"parent_id":7`)
+	})
+
+	t.Run("parent-not-found", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "7"}}
+
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(7)).Return(nil, repository.ErrNotFound).Once()
+		h.PostReply(ctx)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+
+	t.Run("parent-hidden", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "7"}}
+
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(7)).Return(&repository.Comment{ID: 7, Org: "github", IsHidden: true}, nil).Once()
+		h.PostReply(ctx)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+
+	t.Run("not-a-member", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "7"}}
+		jsonBody := `{"author":"awesome-user","comment":"test reply"}`
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
+
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(7)).Return(&repository.Comment{ID: 7}, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(false, nil).Once()
+		h.PostReply(ctx)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+}
+
+func TestListAllCommentsThreadView(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+
+	t.Run("happy-path", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments?view=thread", nil)
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		commentRepoMock.On("ListAll", mock.Anything, "github", mock.MatchedBy(func(opts repository.ListOptions) bool {
+			return opts.RootsOnly
+		})).Return([]repository.Comment{{ID: 1}}, nil).Once()
+		commentRepoMock.On("ListReplies", mock.Anything, "github", []uint64{1}).Return([]repository.Comment{{ID: 2, ParentID: 1}}, nil).Once()
+		h.ListAllComments(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"reply_count":1,"replies":[{"id":2`)
+	})
+
+	t.Run("deleted-root", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments?view=thread", nil)
+
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		commentRepoMock.On("ListAll", mock.Anything, "github", mock.MatchedBy(func(opts repository.ListOptions) bool {
+			return opts.RootsOnly
+		})).Return([]repository.Comment{{ID: 1, Author: "octocat", Comment: "gone", IsDeleted: true, DeletedBy: "octocat"}}, nil).Once()
+		commentRepoMock.On("ListReplies", mock.Anything, "github", []uint64{1}).Return([]repository.Comment{{ID: 2, ParentID: 1, Comment: "still here"}}, nil).Once()
+		h.ListAllComments(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"id":1,"author":"","comment":"",`)
+		assert.Contains(t, respWriter.Body.String(), `"reply_count":1,"replies":[{"id":2`)
+		assert.Contains(t, respWriter.Body.String(), `"deleted":true`)
+		assert.NotContains(t, respWriter.Body.String(), "octocat")
+		assert.NotContains(t, respWriter.Body.String(), "gone")
+	})
+
+	t.Run("bad-view", func(t *testing.T) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments?view=tree", nil)
+
+		h.ListAllComments(ctx)
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+}
+
+func TestBuildThreads(t *testing.T) {
+	roots := []*model.Comment{{ID: 1}, {ID: 2}}
+	replies := []repository.Comment{
+		{ID: 3, ParentID: 1},
+		{ID: 4, ParentID: 3},
+		{ID: 5, ParentID: 1},
+	}
+
+	buildThreads(roots, replies, formatRaw)
+
+	assert.Equal(t, 2, *roots[0].ReplyCount)
+	assert.Equal(t, uint64(3), roots[0].Replies[0].ID)
+	assert.Equal(t, uint64(5), roots[0].Replies[1].ID)
+	assert.Equal(t, 1, *roots[0].Replies[0].ReplyCount)
+	assert.Equal(t, uint64(4), roots[0].Replies[0].Replies[0].ID)
+	assert.Equal(t, 0, *roots[1].ReplyCount)
+}
+
+func TestBuildThreadsDeletedReplies(t *testing.T) {
+	roots := []*model.Comment{{ID: 1}}
+	replies := []repository.Comment{
+		{ID: 2, ParentID: 1, Author: "octocat", Comment: "gone", IsDeleted: true},
+		{ID: 3, ParentID: 1, IsDeleted: true},
+		{ID: 4, ParentID: 2, IsDeleted: true},
+		{ID: 5, ParentID: 4, Comment: "still here"},
+	}
+
+	buildThreads(roots, replies, formatRaw)
+
+	// 3 has no active reply beneath it, 2 and 4 are kept as tombstones for 5.
+	assert.Equal(t, 1, *roots[0].ReplyCount)
+	tombstone := roots[0].Replies[0]
+	assert.Equal(t, &model.Comment{ID: 2, ParentID: 1, Deleted: true, ReplyCount: tombstone.ReplyCount, Replies: tombstone.Replies}, tombstone)
+	assert.True(t, tombstone.Replies[0].Deleted)
+	assert.Equal(t, uint64(5), tombstone.Replies[0].Replies[0].ID)
+	assert.Equal(t, "still here", tombstone.Replies[0].Replies[0].Comment)
+	assert.False(t, tombstone.Replies[0].Replies[0].Deleted)
+}
@@ -0,0 +1,65 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/model"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+// restoreRequest is the request body of RestoreComments.
+type restoreRequest struct {
+	IDs           []uint64 `json:"ids"`
+	DeletionBatch string   `json:"deletion_batch"`
+}
+
+// RestoreComments lets an org admin un-delete soft-deleted comments of an org: all of them,
+// the ones with given IDs or the ones of given deletion batch.
+func (h *handlerImpl) RestoreComments(ctx *gin.Context) {
+	org := ctx.Param("org")
+
+	data, ok := readPostBody(ctx)
+	if !ok {
+		return
+	}
+	req := &restoreRequest{}
+	if len(data) > 0 {
+		err := json.Unmarshal(data, req)
+		if err != nil {
+			log.Printf("ERROR: failed to unmarshal request body, err: %v", err)
+			handlerError(ctx, http.StatusBadRequest, err)
+			return
+		}
+	}
+	if len(req.IDs) > 0 && req.DeletionBatch != "" {
+		handlerError(ctx, http.StatusBadRequest, errors.New("only one of ids and deletion_batch can be set"))
+		return
+	}
+
+	if !h.validOrg(ctx, org) {
+		return
+	}
+
+	login, ok := h.orgAdmin(ctx, org)
+	if !ok {
+		return
+	}
+
+	restored, err := h.commentRepo.Restore(ctx, org, repository.RestoreOptions{IDs: req.IDs, DeletionBatch: req.DeletionBatch})
+	if err == repository.ErrNoData {
+		handlerError(ctx, http.StatusNoContent, err)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+
+	log.Printf("INFO: user %v restored %d comments of org %v", login, restored, org)
+
+	ctx.JSON(http.StatusOK, &model.Restoration{Restored: restored})
+}
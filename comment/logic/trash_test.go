@@ -0,0 +1,142 @@
+package logic
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListTrash(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+	list := func(header http.Header) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments/trash?view=thread", nil)
+		ctx.Request.Header = header
+		h.ListTrash(ctx)
+		return respWriter
+	}
+
+	t.Run("happy-path", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(true, nil).Once()
+		commentRepoMock.On("ListAll", mock.Anything, "github", mock.MatchedBy(func(opts repository.ListOptions) bool {
+			return opts.Deleted && !opts.RootsOnly
+		})).Return([]repository.Comment{{ID: 1, IsDeleted: true, DeletionBatch: "0a1b2c"}}, nil).Once()
+		respWriter := list(authHeader)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"deletion_batch":"0a1b2c"`)
+	})
+
+	t.Run("missing-token", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		respWriter := list(http.Header{})
+		assert.Equal(t, http.StatusUnauthorized, respWriter.Code)
+	})
+
+	t.Run("not-admin", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(false, nil).Once()
+		respWriter := list(authHeader)
+		assert.Equal(t, http.StatusForbidden, respWriter.Code)
+	})
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
+
+func TestRestoreComments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+	restore := func(jsonBody string, header http.Header) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: header}
+		h.RestoreComments(ctx)
+		return respWriter
+	}
+	asAdmin := func() {
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(true, nil).Once()
+	}
+
+	cases := []struct {
+		name     string
+		jsonBody string
+		opts     repository.RestoreOptions
+	}{
+		{"all", ``, repository.RestoreOptions{}},
+		{"by-ids", `{"ids":[1,2]}`, repository.RestoreOptions{IDs: []uint64{1, 2}}},
+		{"by-batch", `{"deletion_batch":"0a1b2c"}`, repository.RestoreOptions{DeletionBatch: "0a1b2c"}},
+	}
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			asAdmin()
+			commentRepoMock.On("Restore", mock.Anything, "github", tc.opts).Return(2, nil).Once()
+			respWriter := restore(tc.jsonBody, authHeader)
+			assert.Equal(t, http.StatusOK, respWriter.Code)
+			assert.Contains(t, respWriter.Body.String(), `"restored":2`)
+		})
+	}
+
+	t.Run("ids-and-batch", func(t *testing.T) {
+		respWriter := restore(`{"ids":[1],"deletion_batch":"0a1b2c"}`, authHeader)
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	t.Run("body-too-large", func(t *testing.T) {
+		respWriter := restore(`{"deletion_batch":"`+strings.Repeat("x", maxPostBodyBytes)+`"}`, authHeader)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, respWriter.Code)
+	})
+
+	t.Run("nothing-to-restore", func(t *testing.T) {
+		asAdmin()
+		commentRepoMock.On("Restore", mock.Anything, "github", repository.RestoreOptions{}).Return(0, repository.ErrNoData).Once()
+		respWriter := restore(`{}`, authHeader)
+		assert.Equal(t, http.StatusNoContent, respWriter.Code)
+	})
+
+	t.Run("missing-token", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		respWriter := restore(`{}`, http.Header{})
+		assert.Equal(t, http.StatusUnauthorized, respWriter.Code)
+	})
+
+	t.Run("not-admin", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, mock.Anything).Return(true, nil).Once()
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		githubMock.On("IsOrgAdmin", mock.Anything, "t0ken", "github").Return(false, nil).Once()
+		respWriter := restore(`{}`, authHeader)
+		assert.Equal(t, http.StatusForbidden, respWriter.Code)
+	})
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
@@ -0,0 +1,197 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/config"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+// names of the built-in validation rules, as reported in 422 responses.
+const (
+	ruleLength          = "length"
+	ruleBlockedWords    = "blocked_words"
+	ruleBlockedPatterns = "blocked_patterns"
+	ruleMaxLinks        = "max_links"
+	ruleDuplicate       = "duplicate"
+)
+
+// linkPattern matches the start of a http(s) link.
+var linkPattern = regexp.MustCompile(`(?i)\bhttps?://`)
+
+// CommentValidator checks a new or edited comment against the validation settings of its org before it is saved.
+// The ID of an edited comment is set.
+// It returns a *ValidationError if the comment breaks its rule and any other error if it could not tell.
+type CommentValidator interface {
+	Validate(ctx context.Context, c *repository.Comment, settings config.ValidationSettings) error
+}
+
+// ValidationError names the rule a comment was rejected by.
+type ValidationError struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error ...
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// defaultValidators returns the built-in rules in the order they are checked, cheapest first.
+func defaultValidators(commentRepo repository.CommentRepo) []CommentValidator {
+	return []CommentValidator{
+		&lengthValidator{},
+		&blockedWordsValidator{},
+		&blockedPatternsValidator{cache: &regexpCache{}},
+		&linkValidator{},
+		&duplicateValidator{commentRepo: commentRepo},
+	}
+}
+
+// validComment runs the validators against given new or edited comment and writes the error response
+// for the first rule it breaks.
+func (h *handlerImpl) validComment(ctx *gin.Context, c *repository.Comment) bool {
+	settings := h.config.ForOrg(c.Org).Validation
+	for _, v := range h.validators {
+		err := v.Validate(ctx, c, settings)
+		if err == nil {
+			continue
+		}
+		if verr, ok := err.(*ValidationError); ok {
+			ctx.JSON(http.StatusUnprocessableEntity, verr)
+			return false
+		}
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return false
+	}
+	return true
+}
+
+// lengthValidator rejects empty comments and comments longer than the max length, which is never more than
+// config.MaxCommentLength.
+type lengthValidator struct{}
+
+// Validate ...
+func (v *lengthValidator) Validate(ctx context.Context, c *repository.Comment, settings config.ValidationSettings) error {
+	if strings.TrimSpace(c.Comment) == "" {
+		return &ValidationError{Rule: ruleLength, Message: "comment must not be empty"}
+	}
+	if max := settings.CommentLength(); utf8.RuneCountInString(c.Comment) > max {
+		return &ValidationError{Rule: ruleLength, Message: fmt.Sprintf("comment must not be longer than %v characters", max)}
+	}
+	return nil
+}
+
+// blockedWordsValidator rejects comments containing a blocked word.
+type blockedWordsValidator struct{}
+
+// Validate ...
+func (v *blockedWordsValidator) Validate(ctx context.Context, c *repository.Comment, settings config.ValidationSettings) error {
+	if len(settings.BlockedWords) == 0 {
+		return nil
+	}
+
+	blocked := make(map[string]bool, len(settings.BlockedWords))
+	for _, w := range settings.BlockedWords {
+		blocked[strings.ToLower(w)] = true
+	}
+	words := strings.FieldsFunc(strings.ToLower(c.Comment), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, w := range words {
+		if blocked[w] {
+			return &ValidationError{Rule: ruleBlockedWords, Message: "comment contains a blocked word"}
+		}
+	}
+	return nil
+}
+
+// blockedPatternsValidator rejects comments matching a blocked regular expression.
+type blockedPatternsValidator struct {
+	cache *regexpCache
+}
+
+// Validate ...
+func (v *blockedPatternsValidator) Validate(ctx context.Context, c *repository.Comment, settings config.ValidationSettings) error {
+	for _, p := range settings.BlockedPatterns {
+		re, err := v.cache.get(p)
+		if err != nil {
+			return err
+		}
+		if re.MatchString(c.Comment) {
+			return &ValidationError{Rule: ruleBlockedPatterns, Message: "comment matches a blocked pattern"}
+		}
+	}
+	return nil
+}
+
+// regexpCache compiles every pattern only once.
+type regexpCache struct {
+	mu       sync.Mutex
+	compiled map[string]*regexp.Regexp
+}
+
+// get returns the compiled pattern.
+func (c *regexpCache) get(pattern string) (*regexp.Regexp, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if re, ok := c.compiled[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	if c.compiled == nil {
+		c.compiled = map[string]*regexp.Regexp{}
+	}
+	c.compiled[pattern] = re
+	return re, nil
+}
+
+// linkValidator rejects comments with more than the allowed number of links.
+type linkValidator struct{}
+
+// Validate ...
+func (v *linkValidator) Validate(ctx context.Context, c *repository.Comment, settings config.ValidationSettings) error {
+	if settings.MaxLinks <= 0 {
+		return nil
+	}
+	if n := len(linkPattern.FindAllStringIndex(c.Comment, -1)); n > settings.MaxLinks {
+		return &ValidationError{Rule: ruleMaxLinks, Message: fmt.Sprintf("comment must not contain more than %v links", settings.MaxLinks)}
+	}
+	return nil
+}
+
+// duplicateValidator rejects a comment the author already posted to the org within the duplicate window.
+// Edits are not checked.
+type duplicateValidator struct {
+	commentRepo repository.CommentRepo
+}
+
+// Validate ...
+func (v *duplicateValidator) Validate(ctx context.Context, c *repository.Comment, settings config.ValidationSettings) error {
+	if settings.DuplicateWindow <= 0 || c.ID != 0 {
+		return nil
+	}
+
+	since := time.Now().Add(-time.Duration(settings.DuplicateWindow))
+	duplicate, err := v.commentRepo.HasDuplicate(ctx, c.Org, c.Author, c.Comment, since)
+	if err != nil {
+		return err
+	}
+	if duplicate {
+		return &ValidationError{Rule: ruleDuplicate, Message: "the same comment was posted recently"}
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/config"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestValidators(t *testing.T) {
+	commentRepoMock := &repository.MockCommentRepo{}
+	validators := defaultValidators(commentRepoMock)
+	settings := config.ValidationSettings{
+		MaxLength:       20,
+		BlockedWords:    []string{"Darn"},
+		BlockedPatterns: []string{`(?i)buy\s+now`},
+		MaxLinks:        1,
+		DuplicateWindow: config.Duration(time.Minute),
+	}
+
+	cases := []struct {
+		name    string
+		comment string
+		rule    string
+	}{
+		{"valid", "looks good to me", ""},
+		{"empty", "  ", ruleLength},
+		{"too-long", strings.Repeat("a", 21), ruleLength},
+		{"multi-byte", strings.Repeat("ü", 20), ""},
+		{"blocked-word", "well, DARN it", ruleBlockedWords},
+		{"blocked-word-substring", "darned", ""},
+		{"blocked-pattern", "Buy   now!", ruleBlockedPatterns},
+		{"one-link", "see http://a", ""},
+		{"too-many-links", "http://a https://b", ruleMaxLinks},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &repository.Comment{Org: "github", Author: "awesome-user", Comment: tc.comment}
+			if tc.rule == "" {
+				commentRepoMock.On("HasDuplicate", mock.Anything, "github", "awesome-user", tc.comment, mock.Anything).Return(false, nil).Once()
+			}
+
+			var rule string
+			for _, v := range validators {
+				if err := v.Validate(context.Background(), c, settings); err != nil {
+					rule = err.(*ValidationError).Rule
+					break
+				}
+			}
+			assert.Equal(t, tc.rule, rule)
+		})
+	}
+
+	t.Run("duplicate", func(t *testing.T) {
+		c := &repository.Comment{Org: "github", Author: "awesome-user", Comment: "+1"}
+		since := mock.MatchedBy(func(since time.Time) bool { return time.Since(since) >= time.Minute })
+		commentRepoMock.On("HasDuplicate", mock.Anything, "github", "awesome-user", "+1", since).Return(true, nil).Once()
+
+		err := (&duplicateValidator{commentRepo: commentRepoMock}).Validate(context.Background(), c, settings)
+		assert.Equal(t, &ValidationError{Rule: ruleDuplicate, Message: "the same comment was posted recently"}, err)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		c := &repository.Comment{Org: "github", Author: "awesome-user", Comment: strings.Repeat("http://a.io ", 40)}
+		for _, v := range validators {
+			assert.NoError(t, v.Validate(context.Background(), c, config.ValidationSettings{}))
+		}
+	})
+
+	t.Run("length-cap", func(t *testing.T) {
+		c := &repository.Comment{Org: "github", Author: "awesome-user", Comment: strings.Repeat("a", config.MaxCommentLength+1)}
+		for _, max := range []int{0, 1000} {
+			err := (&lengthValidator{}).Validate(context.Background(), c, config.ValidationSettings{MaxLength: max})
+			assert.Equal(t, &ValidationError{Rule: ruleLength, Message: "comment must not be longer than 512 characters"}, err)
+		}
+	})
+}
+
+func TestPostCommentValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+		config: &config.Config{
+			OrgDefaults: config.OrgSettings{Validation: config.ValidationSettings{MaxLength: 10, DuplicateWindow: config.Duration(time.Minute)}},
+		},
+		validators: defaultValidators(commentRepoMock),
+	}
+	newContext := func(jsonBody string) (*gin.Context, *httptest.ResponseRecorder) {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		return ctx, respWriter
+	}
+
+	t.Run("rule-broken", func(t *testing.T) {
+		ctx, respWriter := newContext(`{"comment":"far too long comment"}`)
+		h.PostComment(ctx)
+		assert.Equal(t, http.StatusUnprocessableEntity, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"rule":"length"`)
+	})
+
+	t.Run("validator-err", func(t *testing.T) {
+		ctx, respWriter := newContext(`{"comment":"short"}`)
+		commentRepoMock.On("HasDuplicate", mock.Anything, "github", "awesome-user", "short", mock.Anything).Return(false, errors.New("some repo error")).Once()
+		h.PostComment(ctx)
+		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+	})
+
+	t.Run("valid", func(t *testing.T) {
+		ctx, respWriter := newContext(`{"comment":"short"}`)
+		commentRepoMock.On("HasDuplicate", mock.Anything, "github", "awesome-user", "short", mock.Anything).Return(false, nil).Once()
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("Save", mock.Anything, mock.Anything).Return(nil).Once()
+		h.PostComment(ctx)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+	})
+}
+
+func TestUpdateCommentValidation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+		config: &config.Config{
+			OrgDefaults: config.OrgSettings{Validation: config.ValidationSettings{
+				MaxLength:       20,
+				BlockedWords:    []string{"darn"},
+				DuplicateWindow: config.Duration(time.Minute),
+			}},
+		},
+		validators: defaultValidators(commentRepoMock),
+	}
+	update := func(jsonBody string) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: editHeader}
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		h.UpdateComment(ctx)
+		return respWriter
+	}
+
+	t.Run("blocked-word", func(t *testing.T) {
+		respWriter := update(`{"comment":"well, darn it"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"rule":"blocked_words"`)
+	})
+
+	t.Run("too-long", func(t *testing.T) {
+		respWriter := update(`{"comment":"` + strings.Repeat("a", 21) + `"}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"rule":"length"`)
+	})
+
+	t.Run("valid-without-duplicate-check", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1, Author: "awesome-user", Version: 1}, nil).Once()
+		commentRepoMock.On("Update", mock.Anything, mock.Anything).Return(nil).Once()
+		respWriter := update(`{"comment":"fine"}`)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+	})
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
@@ -0,0 +1,70 @@
+package logic
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+)
+
+var errInvalidVote = errors.New("value must be 1 to vote up, -1 to vote down or 0 to withdraw the vote")
+
+// voteRequest is the request body of VoteComment.
+type voteRequest struct {
+	Value *int `json:"value"`
+}
+
+// VoteComment lets a member of the org vote a comment up or down, or withdraw their vote.
+func (h *handlerImpl) VoteComment(ctx *gin.Context) {
+	org := ctx.Param("org")
+	id, ok := commentID(ctx)
+	if !ok {
+		return
+	}
+
+	login, ok := h.currentUser(ctx)
+	if !ok {
+		return
+	}
+
+	data, ok := readPostBody(ctx)
+	if !ok {
+		return
+	}
+	req := &voteRequest{}
+	err := json.Unmarshal(data, req)
+	if err != nil {
+		log.Printf("ERROR: failed to unmarshal request body, err: %v", err)
+		handlerError(ctx, http.StatusBadRequest, err)
+		return
+	}
+	if req.Value == nil || *req.Value < -1 || *req.Value > 1 {
+		handlerError(ctx, http.StatusBadRequest, errInvalidVote)
+		return
+	}
+
+	isValid, err := h.github.IsMember(ctx, org, login)
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	if !isValid {
+		log.Printf("INFO: user %v is not a member of org %v", login, org)
+		handlerError(ctx, http.StatusNotFound, errors.New("user is not a member of specified org"))
+		return
+	}
+
+	err = h.commentRepo.Vote(ctx, org, &repository.Vote{CommentID: id, Voter: login, Value: *req.Value})
+	if err == repository.ErrNotFound {
+		handlerError(ctx, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		handlerError(ctx, http.StatusInternalServerError, err)
+		return
+	}
+	h.writeComment(ctx, org, id, http.StatusOK)
+}
@@ -0,0 +1,142 @@
+package logic
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/repository"
+	"github.com/rahulbharuka/github-proxy/external/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestVoteComment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+	vote := func(jsonBody string) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}, gin.Param{Key: "id", Value: "1"}}
+		body := ioutil.NopCloser(bytes.NewReader([]byte(jsonBody)))
+		ctx.Request = &http.Request{Body: body, Header: authHeader}
+		githubMock.On("GetAuthenticatedUser", mock.Anything, "t0ken").Return("awesome-user", nil).Once()
+		h.VoteComment(ctx)
+		return respWriter
+	}
+
+	t.Run("up-vote", func(t *testing.T) {
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("Vote", mock.Anything, "github", &repository.Vote{CommentID: 1, Voter: "awesome-user", Value: 1}).Return(nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1, Score: 3, Version: 4}, nil).Once()
+		respWriter := vote(`{"value":1}`)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"score":3`)
+		assert.Regexp(t, `^W/"4-[0-9a-f]{16}"$`, respWriter.Header().Get("ETag"))
+	})
+
+	t.Run("withdraw-vote", func(t *testing.T) {
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("Vote", mock.Anything, "github", &repository.Vote{CommentID: 1, Voter: "awesome-user", Value: 0}).Return(nil).Once()
+		commentRepoMock.On("Get", mock.Anything, "github", uint64(1)).Return(&repository.Comment{ID: 1, Score: 2}, nil).Once()
+		respWriter := vote(`{"value":0}`)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"score":2`)
+	})
+
+	t.Run("invalid-value", func(t *testing.T) {
+		for _, jsonBody := range []string{`{"value":2}`, `{}`, `{"value":"up"}`} {
+			respWriter := vote(jsonBody)
+			assert.Equal(t, http.StatusBadRequest, respWriter.Code, jsonBody)
+		}
+	})
+
+	t.Run("body-too-large", func(t *testing.T) {
+		respWriter := vote(`{"value":1,"pad":"` + strings.Repeat("x", maxPostBodyBytes) + `"}`)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, respWriter.Code)
+	})
+
+	t.Run("not-a-member", func(t *testing.T) {
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(false, nil).Once()
+		respWriter := vote(`{"value":-1}`)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+
+	t.Run("comment-not-found", func(t *testing.T) {
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("Vote", mock.Anything, "github", mock.Anything).Return(repository.ErrNotFound).Once()
+		respWriter := vote(`{"value":-1}`)
+		assert.Equal(t, http.StatusNotFound, respWriter.Code)
+	})
+
+	t.Run("repo-err", func(t *testing.T) {
+		githubMock.On("IsMember", mock.Anything, "github", "awesome-user").Return(true, nil).Once()
+		commentRepoMock.On("Vote", mock.Anything, "github", mock.Anything).Return(errors.New("some repo error")).Once()
+		respWriter := vote(`{"value":-1}`)
+		assert.Equal(t, http.StatusInternalServerError, respWriter.Code)
+	})
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
+
+func TestListRankedComments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	githubMock := &github.MockHandler{}
+	commentRepoMock := &repository.MockCommentRepo{}
+	h := &handlerImpl{
+		github:      githubMock,
+		commentRepo: commentRepoMock,
+	}
+	list := func(query string) *httptest.ResponseRecorder {
+		respWriter := httptest.NewRecorder()
+		ctx, _ := gin.CreateTestContext(respWriter)
+		ctx.Params = []gin.Param{gin.Param{Key: "org", Value: "github"}}
+		ctx.Request = httptest.NewRequest(http.MethodGet, "/orgs/github/comments?"+query, nil)
+		h.ListAllComments(ctx)
+		return respWriter
+	}
+	rankedAt := time.Date(2020, 2, 21, 13, 0, 0, 0, time.UTC)
+
+	t.Run("top", func(t *testing.T) {
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		commentRepoMock.On("ListAll", mock.Anything, "github", repository.ListOptions{Limit: 3, PinnedFirst: true, Rank: repository.RankTop}).
+			Return([]repository.Comment{{ID: 2, Score: 5}, {ID: 1, Score: 1}, {ID: 3}}, nil).Once()
+		respWriter := list("sort=top&limit=2")
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"score":5`)
+		assert.Contains(t, respWriter.Body.String(), `"next_cursor":"`+encodeCursor(&repository.Cursor{Offset: 2})+`"`)
+	})
+
+	t.Run("hot-next-page", func(t *testing.T) {
+		cursor := encodeCursor(&repository.Cursor{Offset: 2, RankedAt: &rankedAt})
+		githubMock.On("IsValidOrg", mock.Anything, "github").Return(true, nil).Once()
+		commentRepoMock.On("ListAll", mock.Anything, "github", mock.MatchedBy(func(opts repository.ListOptions) bool {
+			return opts.Rank == repository.RankHot && opts.Offset == 2 && opts.RankedAt.Equal(rankedAt) && opts.After == nil
+		})).Return([]repository.Comment{{ID: 4}, {ID: 5}, {ID: 6}}, nil).Once()
+		respWriter := list("sort=hot&limit=2&cursor=" + cursor)
+		assert.Equal(t, http.StatusOK, respWriter.Code)
+		assert.Contains(t, respWriter.Body.String(), `"next_cursor":"`+encodeCursor(&repository.Cursor{Offset: 4, RankedAt: &rankedAt})+`"`)
+	})
+
+	t.Run("cursor-of-other-sort", func(t *testing.T) {
+		respWriter := list("sort=hot&cursor=" + encodeCursor(&repository.Cursor{ID: 1}))
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+		respWriter = list("cursor=" + encodeCursor(&repository.Cursor{Offset: 2}))
+		assert.Equal(t, http.StatusBadRequest, respWriter.Code)
+	})
+
+	githubMock.AssertExpectations(t)
+	commentRepoMock.AssertExpectations(t)
+}
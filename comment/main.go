@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 
@@ -17,6 +18,20 @@ func main() {
 	// set release mode logging.
 	gin.SetMode(gin.ReleaseMode)
 
+	// get logic handler
+	h := logic.GetHandler()
+
+	// purge expired deleted comments in background
+	go h.RunPurger(context.Background())
+
+	router := newRouter(h)
+
+	// run app on the specified port
+	router.Run(":" + port)
+}
+
+// newRouter returns the router serving the API of given handler.
+func newRouter(h logic.Handler) *gin.Engine {
 	// create default Gin router
 	router := gin.New()
 
@@ -26,14 +41,36 @@ func main() {
 	// init recovery middleware
 	router.Use(gin.Recovery())
 
-	// get logic handler
-	h := logic.GetHandler()
-
 	// API handlers.
-	router.POST("/orgs/:org/comments", h.PostComment)
+	router.POST("/orgs/:org/comments", h.Idempotent, h.LimitPosts, h.PostComment)
 	router.GET("/orgs/:org/comments", h.ListAllComments)
 	router.DELETE("/orgs/:org/comments", h.DeleteAllComments)
+	router.GET("/orgs/:org/comments/search", h.SearchComments)
+	router.GET("/orgs/:org/comments/trash", h.ListTrash)
+	router.GET("/orgs/:org/comments/export", h.ExportComments)
+	router.POST("/orgs/:org/comments/import", h.ImportComments)
+	router.POST("/orgs/:org/comments/restore", h.RestoreComments)
+	router.GET("/orgs/:org/comments/:id", h.GetComment)
+	router.PATCH("/orgs/:org/comments/:id", h.UpdateComment)
+	router.DELETE("/orgs/:org/comments/:id", h.DeleteComment)
+	router.POST("/orgs/:org/comments/:id/replies", h.LimitPosts, h.PostReply)
+	router.GET("/orgs/:org/comments/:id/revisions", h.ListRevisions)
+	router.POST("/orgs/:org/comments/:id/flags", h.FlagComment)
+	router.POST("/orgs/:org/comments/:id/reactions", h.ReactToComment)
+	router.DELETE("/orgs/:org/comments/:id/reactions", h.RemoveReaction)
+	router.POST("/orgs/:org/comments/:id/vote", h.VoteComment)
+	router.POST("/orgs/:org/comments/:id/pin", h.PinComment)
+	router.DELETE("/orgs/:org/comments/:id/pin", h.UnpinComment)
+	router.POST("/orgs/:org/repos/:repo/comments", h.Idempotent, h.LimitPosts, h.PostComment)
+	router.GET("/orgs/:org/repos/:repo/comments", h.ListAllComments)
+	router.POST("/orgs/:org/repos/:repo/issues/:number/comments", h.Idempotent, h.LimitPosts, h.PostComment)
+	router.GET("/orgs/:org/repos/:repo/issues/:number/comments", h.ListAllComments)
+	router.GET("/orgs/:org/tags", h.ListTags)
+	router.GET("/orgs/:org/moderation/queue", h.ModerationQueue)
+	router.POST("/orgs/:org/moderation/comments/:id/approve", h.ApproveComment)
+	router.POST("/orgs/:org/moderation/comments/:id/hide", h.HideComment)
+	router.GET("/users/:user/mentions", h.ListMentions)
+	router.POST("/admin/purge", h.PurgeComments)
 
-	// run app on the specified port
-	router.Run(":" + port)
+	return router
 }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rahulbharuka/github-proxy/comment/logic"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// gin panics on conflicting routes, and before v1.7 on static segments next to a wildcard,
+	// e.g. /orgs/:org/comments/search next to /orgs/:org/comments/:id.
+	var router *gin.Engine
+	assert.NotPanics(t, func() { router = newRouter(logic.GetHandler()) })
+	if router == nil {
+		return
+	}
+
+	routes := map[string]bool{}
+	for _, r := range router.Routes() {
+		routes[r.Method+" "+r.Path] = true
+	}
+	for _, route := range []string{
+		http.MethodGet + " /orgs/:org/comments/search",
+		http.MethodGet + " /orgs/:org/comments/trash",
+		http.MethodGet + " /orgs/:org/comments/export",
+		http.MethodPost + " /orgs/:org/comments/import",
+		http.MethodPost + " /orgs/:org/comments/restore",
+		http.MethodGet + " /orgs/:org/comments/:id",
+	} {
+		assert.True(t, routes[route], "missing route %v", route)
+	}
+}
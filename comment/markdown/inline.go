@@ -0,0 +1,451 @@
+package markdown
+
+import (
+	"html"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// kinds of inlines.
+const (
+	inlineText = iota
+	inlineBreak
+	inlineCode
+	inlineEmphasis
+	inlineStrong
+	inlineStrike
+	inlineLink
+	inlineImage
+)
+
+// inline is a parsed span of text within a block.
+type inline struct {
+	kind int
+	// text of a text span or of a code span.
+	text string
+	// url of a link or an image, it is always safe to render.
+	url      string
+	children []inline
+}
+
+// delimiters of the emphasis-like spans, longest first.
+var delimiters = []struct {
+	delim string
+	kind  int
+}{
+	{"**", inlineStrong},
+	{"__", inlineStrong},
+	{"~~", inlineStrike},
+	{"*", inlineEmphasis},
+	{"_", inlineEmphasis},
+}
+
+// parser parses the inlines of a text.
+type parser struct {
+	src string
+	// inLink is set while parsing the text of a link, which can not hold another link.
+	inLink bool
+	out    []inline
+	text   strings.Builder
+}
+
+// parseInlines parses given text of a block into inlines.
+func parseInlines(src string) []inline {
+	p := &parser{src: src}
+	return p.parse()
+}
+
+// flush turns the pending text into a text inline.
+func (p *parser) flush() {
+	if p.text.Len() > 0 {
+		p.out = append(p.out, inline{kind: inlineText, text: p.text.String()})
+		p.text.Reset()
+	}
+}
+
+// add adds a parsed inline after the pending text.
+func (p *parser) add(in inline) {
+	p.flush()
+	p.out = append(p.out, in)
+}
+
+// sub parses a part of the text, e.g. the content of an emphasis.
+func (p *parser) sub(src string) []inline {
+	s := &parser{src: src, inLink: p.inLink}
+	return s.parse()
+}
+
+func (p *parser) parse() []inline {
+	s := p.src
+	for i := 0; i < len(s); {
+		if n := p.parseAt(i); n > 0 {
+			i += n
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		p.text.WriteString(s[i : i+size])
+		i += size
+	}
+	p.flush()
+	return p.out
+}
+
+// parseAt tries to parse an inline at byte offset i. It returns the length of the parsed source, 0 if none.
+func (p *parser) parseAt(i int) int {
+	s := p.src
+	switch c := s[i]; c {
+	case '\\':
+		if i+1 < len(s) && isPunct(s[i+1]) {
+			p.text.WriteByte(s[i+1])
+			return 2
+		}
+	case '\n':
+		p.add(inline{kind: inlineBreak})
+		return 1
+	case '`':
+		return p.parseCode(i)
+	case '!':
+		if i+1 < len(s) && s[i+1] == '[' {
+			return p.parseLink(i+1, true)
+		}
+	case '[':
+		return p.parseLink(i, false)
+	case '<':
+		return p.parseAutolink(i)
+	case '*', '_', '~':
+		return p.parseDelimited(i)
+	case 'h', 'w', 'H', 'W':
+		return p.parseBareLink(i)
+	}
+	return 0
+}
+
+// parseCode parses a code span, e.g. `x`, starting at i.
+func (p *parser) parseCode(i int) int {
+	s := p.src
+	n := runLength(s, i)
+	fence := s[i : i+n]
+	for j := i + n; j < len(s); {
+		k := strings.Index(s[j:], fence)
+		if k < 0 {
+			break
+		}
+		j += k
+		if m := runLength(s, j); m != n {
+			j += m
+			continue
+		}
+		code := strings.Replace(s[i+n:j], "\n", " ", -1)
+		if len(code) > 2 && code[0] == ' ' && code[len(code)-1] == ' ' && strings.TrimSpace(code) != "" {
+			code = code[1 : len(code)-1]
+		}
+		p.add(inline{kind: inlineCode, text: code})
+		return j + n - i
+	}
+	// an unmatched run of backticks is literal text.
+	p.text.WriteString(fence)
+	return n
+}
+
+// parseLink parses a link, e.g. [text](url), or an image if image is set, starting at the [ at i.
+func (p *parser) parseLink(i int, image bool) int {
+	if p.inLink && !image {
+		return 0
+	}
+	s := p.src
+	end := closingBracket(s, i)
+	if end < 0 || end+1 >= len(s) || s[end+1] != '(' {
+		return 0
+	}
+	close := strings.IndexByte(s[end+1:], ')')
+	if close < 0 {
+		return 0
+	}
+	close += end + 1
+	dest := strings.TrimSpace(s[end+2 : close])
+	// an optional title is left out.
+	if k := strings.IndexAny(dest, " \t\n"); k >= 0 {
+		dest = dest[:k]
+	}
+	dest = strings.TrimSuffix(strings.TrimPrefix(dest, "<"), ">")
+
+	start := i
+	if image {
+		start--
+	}
+	link, ok := safeURL(dest, !image)
+	if !ok {
+		// unsafe links are left as text, the text of the link included.
+		return 0
+	}
+
+	text := s[i+1 : end]
+	if image {
+		p.add(inline{kind: inlineImage, url: link, text: inlinePlain(p.sub(text))})
+	} else {
+		sub := &parser{src: text, inLink: true}
+		p.add(inline{kind: inlineLink, url: link, children: sub.parse()})
+	}
+	return close + 1 - start
+}
+
+// parseAutolink parses an autolink, e.g. <https://github.com>, starting at i.
+func (p *parser) parseAutolink(i int) int {
+	s := p.src
+	end := strings.IndexAny(s[i+1:], "<> \t\n")
+	if end < 0 || s[i+1+end] != '>' || p.inLink {
+		return 0
+	}
+	raw := s[i+1 : i+1+end]
+	link, ok := safeURL(raw, true)
+	if !ok || !strings.Contains(raw, ":") {
+		return 0
+	}
+	p.add(inline{kind: inlineLink, url: link, children: []inline{{kind: inlineText, text: raw}}})
+	return end + 2
+}
+
+// parseBareLink parses an URL in the text, e.g. https://github.com or www.github.com, starting at i.
+func (p *parser) parseBareLink(i int) int {
+	s := p.src
+	if p.inLink || (i > 0 && isWordByte(s[i-1])) {
+		return 0
+	}
+	lower := strings.ToLower(s[i:min(len(s), i+8)])
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") && !strings.HasPrefix(lower, "www.") {
+		return 0
+	}
+
+	end := strings.IndexAny(s[i:], " \t\n<")
+	if end < 0 {
+		end = len(s) - i
+	}
+	raw := trimLinkEnd(s[i : i+end])
+	if len(raw) <= len("www.") || strings.HasSuffix(strings.ToLower(raw), "://") {
+		return 0
+	}
+	dest := raw
+	if strings.HasPrefix(strings.ToLower(raw), "www.") {
+		dest = "http://" + raw
+	}
+	link, ok := safeURL(dest, false)
+	if !ok {
+		return 0
+	}
+	p.add(inline{kind: inlineLink, url: link, children: []inline{{kind: inlineText, text: raw}}})
+	return len(raw)
+}
+
+// parseDelimited parses an emphasis, a strong emphasis or a strikethrough starting at i.
+func (p *parser) parseDelimited(i int) int {
+	s := p.src
+	run := runLength(s, i)
+	for _, d := range delimiters {
+		n := len(d.delim)
+		if run != n || !strings.HasPrefix(s[i:], d.delim) {
+			continue
+		}
+		// the opening delimiter must be followed by text, and _ must not be within a word, e.g. snake_case.
+		if i+n >= len(s) || isSpace(s[i+n]) || (s[i] == '_' && i > 0 && isWordByte(s[i-1])) {
+			break
+		}
+		j := closingDelimiter(s, i+n, d.delim)
+		if j < 0 {
+			break
+		}
+		p.add(inline{kind: d.kind, children: p.sub(s[i+n : j])})
+		return j + n - i
+	}
+	// an unmatched run of delimiters is literal text.
+	p.text.WriteString(s[i : i+run])
+	return run
+}
+
+// closingDelimiter returns the offset of the delimiter closing a span whose content starts at from, or -1.
+func closingDelimiter(s string, from int, delim string) int {
+	for j := from; j < len(s); {
+		switch {
+		case s[j] == '\\':
+			j += 2
+		case s[j] == '`':
+			j += codeSpanLength(s, j)
+		case strings.HasPrefix(s[j:], delim):
+			run := runLength(s, j)
+			if run == len(delim) && j > from && !isSpace(s[j-1]) &&
+				!(delim[0] == '_' && j+run < len(s) && isWordByte(s[j+run])) {
+				return j
+			}
+			j += run
+		default:
+			j++
+		}
+	}
+	return -1
+}
+
+// closingBracket returns the offset of the ] matching the [ at i, or -1.
+func closingBracket(s string, i int) int {
+	depth := 0
+	for j := i; j < len(s); {
+		switch s[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			j += codeSpanLength(s, j)
+			continue
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return j
+			}
+		}
+		j++
+	}
+	return -1
+}
+
+// codeSpanLength returns the length of the code span starting at i, or of its opening backticks if it is not closed.
+func codeSpanLength(s string, i int) int {
+	n := runLength(s, i)
+	for j := i + n; j < len(s); {
+		k := strings.Index(s[j:], s[i:i+n])
+		if k < 0 {
+			break
+		}
+		j += k
+		m := runLength(s, j)
+		if m == n {
+			return j + n - i
+		}
+		j += m
+	}
+	return n
+}
+
+// runLength returns the number of repetitions of the byte at i.
+func runLength(s string, i int) int {
+	n := 1
+	for i+n < len(s) && s[i+n] == s[i] {
+		n++
+	}
+	return n
+}
+
+// trimLinkEnd strips the trailing punctuation of a bare link, along with the ) closing a parenthesis around it.
+func trimLinkEnd(raw string) string {
+	for raw != "" {
+		last := raw[len(raw)-1]
+		switch {
+		case strings.IndexByte(".,:;!?\"'*_~", last) >= 0:
+			raw = raw[:len(raw)-1]
+		case last == ')' && strings.Count(raw, "(") < strings.Count(raw, ")"):
+			raw = raw[:len(raw)-1]
+		default:
+			return raw
+		}
+	}
+	return raw
+}
+
+// safeURL checks that the link is an absolute http or https URL, or a mailto URL if mailto is set.
+// It returns the normalized URL.
+func safeURL(link string, mailto bool) (string, bool) {
+	if link == "" || strings.IndexFunc(link, func(r rune) bool { return unicode.IsSpace(r) || unicode.IsControl(r) }) >= 0 {
+		return "", false
+	}
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", false
+	}
+	switch u.Scheme {
+	case "http", "https":
+		if u.Host == "" {
+			return "", false
+		}
+	case "mailto":
+		if !mailto || u.Opaque == "" {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+	return u.String(), true
+}
+
+// isPunct tells whether the byte is an ASCII punctuation character, which can be escaped with a backslash.
+func isPunct(c byte) bool {
+	return strings.IndexByte("!\"#$%&'()*+,-./:;<=>?@[\\]^_`{|}~", c) >= 0
+}
+
+// isSpace tells whether the byte is whitespace.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n'
+}
+
+// isWordByte tells whether the byte is part of a word, non-ASCII bytes included.
+func isWordByte(c byte) bool {
+	return c >= 0x80 || c == '_' || ('0' <= c && c <= '9') || ('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z')
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// writeInlines writes the HTML of given inlines.
+func writeInlines(b *strings.Builder, inlines []inline) {
+	for _, in := range inlines {
+		switch in.kind {
+		case inlineText:
+			b.WriteString(html.EscapeString(in.text))
+		case inlineBreak:
+			b.WriteString("<br>\n")
+		case inlineCode:
+			b.WriteString("<code>" + html.EscapeString(in.text) + "</code>")
+		case inlineEmphasis:
+			writeTag(b, "em", in.children)
+		case inlineStrong:
+			writeTag(b, "strong", in.children)
+		case inlineStrike:
+			writeTag(b, "del", in.children)
+		case inlineLink:
+			b.WriteString(`<a href="` + html.EscapeString(in.url) + `" rel="nofollow noopener noreferrer">`)
+			writeInlines(b, in.children)
+			b.WriteString("</a>")
+		case inlineImage:
+			b.WriteString(`<img src="` + html.EscapeString(in.url) + `" alt="` + html.EscapeString(in.text) + `">`)
+		}
+	}
+}
+
+// writeTag writes given inlines wrapped in a tag.
+func writeTag(b *strings.Builder, tag string, children []inline) {
+	b.WriteString("<" + tag + ">")
+	writeInlines(b, children)
+	b.WriteString("</" + tag + ">")
+}
+
+// inlinePlain returns the plain text of given inlines, keeping line breaks.
+func inlinePlain(inlines []inline) string {
+	var b strings.Builder
+	for _, in := range inlines {
+		switch in.kind {
+		case inlineText, inlineCode:
+			b.WriteString(in.text)
+		case inlineBreak:
+			b.WriteByte('\n')
+		case inlineImage:
+			b.WriteString(in.text)
+		default:
+			b.WriteString(inlinePlain(in.children))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,343 @@
+// Package markdown renders comments written in a subset of Github flavoured Markdown as HTML or plain text.
+//
+// Only paragraphs, ATX headings, fenced code blocks, block quotes, flat (task) lists, thematic breaks,
+// code spans, emphasis, strikethrough, inline links, images and autolinks are supported. Every line break
+// within a paragraph becomes a <br>, as in Github comments. Anything else is rendered as the text of a
+// paragraph, e.g. tables, nested lists, reference links, setext headings, indented code and raw HTML.
+//
+// Raw HTML is never passed through: all text is escaped and every tag of the output is written
+// by the renderer, so the HTML is safe to embed as it is. Links and images are only rendered for
+// http, https (and mailto for links) URLs, others are left as text.
+package markdown
+
+import (
+	"html"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// kinds of blocks.
+const (
+	blockParagraph = iota
+	blockHeading
+	blockCode
+	blockQuote
+	blockList
+	blockRule
+)
+
+// block is a parsed block of a Markdown document.
+type block struct {
+	kind int
+	// level of a heading.
+	level int
+	// text of a paragraph or a heading, or the content of a code block.
+	text string
+	// lang is the info string of a fenced code block.
+	lang string
+	// children of a block quote.
+	children []block
+	// ordered lists start at start.
+	ordered bool
+	start   int
+	items   []listItem
+}
+
+// listItem is an item of a list. task is -1 for regular items and 0 or 1 for (un)checked task items.
+type listItem struct {
+	text string
+	task int
+}
+
+var (
+	headingPattern  = regexp.MustCompile(`^ {0,3}(#{1,6})(?:[ \t]+(.*?))?(?:[ \t]+#+)?[ \t]*$`)
+	fencePattern    = regexp.MustCompile("^ {0,3}(`{3,}|~{3,})[ \t]*([^`]*)$")
+	quotePattern    = regexp.MustCompile(`^ {0,3}> ?(.*)$`)
+	listItemPattern = regexp.MustCompile(`^ {0,3}([-*+]|\d{1,9}[.)])(?:[ \t]+(.*))?$`)
+	taskPattern     = regexp.MustCompile(`^\[([ xX])\](?:[ \t]+|$)`)
+	langPattern     = regexp.MustCompile(`^[A-Za-z0-9_+#.-]{1,32}$`)
+)
+
+// HTML renders given Markdown source as sanitized HTML.
+func HTML(src string) string {
+	var b strings.Builder
+	writeBlocks(&b, parseBlocks(splitLines(src)))
+	return b.String()
+}
+
+// Text renders given Markdown source as plain text, without any markup.
+func Text(src string) string {
+	parts := textBlocks(parseBlocks(splitLines(src)))
+	return strings.Join(parts, "\n\n")
+}
+
+// splitLines splits the source into lines, whatever their line endings.
+func splitLines(src string) []string {
+	src = strings.Replace(src, "\r\n", "\n", -1)
+	src = strings.Replace(src, "\r", "\n", -1)
+	return strings.Split(src, "\n")
+}
+
+// isBlank tells whether the line holds whitespace only.
+func isBlank(line string) bool {
+	return strings.TrimSpace(line) == ""
+}
+
+// isRule tells whether the line is a thematic break, e.g. --- or * * *.
+func isRule(line string) bool {
+	s := strings.TrimLeft(line, " ")
+	if len(line)-len(s) > 3 || s == "" {
+		return false
+	}
+	c := s[0]
+	if c != '-' && c != '*' && c != '_' {
+		return false
+	}
+	n := 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case c:
+			n++
+		case ' ', '\t':
+		default:
+			return false
+		}
+	}
+	return n >= 3
+}
+
+// startsBlock tells whether the line starts a block other than a paragraph, so that it ends a paragraph.
+func startsBlock(line string) bool {
+	return isRule(line) || headingPattern.MatchString(line) || fencePattern.MatchString(line) ||
+		quotePattern.MatchString(line) || listItemPattern.MatchString(line)
+}
+
+// parseBlocks parses the lines of a document, or of a block quote, into blocks.
+func parseBlocks(lines []string) []block {
+	var blocks []block
+	for i := 0; i < len(lines); {
+		line := lines[i]
+		switch {
+		case isBlank(line):
+			i++
+
+		case isRule(line):
+			blocks = append(blocks, block{kind: blockRule})
+			i++
+
+		case headingPattern.MatchString(line):
+			m := headingPattern.FindStringSubmatch(line)
+			blocks = append(blocks, block{kind: blockHeading, level: len(m[1]), text: m[2]})
+			i++
+
+		case fencePattern.MatchString(line):
+			m := fencePattern.FindStringSubmatch(line)
+			fence := m[1]
+			b := block{kind: blockCode}
+			if info := strings.Fields(m[2]); len(info) > 0 && langPattern.MatchString(info[0]) {
+				b.lang = info[0]
+			}
+			var code []string
+			for i++; i < len(lines); i++ {
+				closing := strings.TrimSpace(lines[i])
+				if strings.HasPrefix(closing, fence) && strings.Trim(closing, fence[:1]) == "" {
+					i++
+					break
+				}
+				code = append(code, lines[i])
+			}
+			if len(code) > 0 {
+				b.text = strings.Join(code, "\n") + "\n"
+			}
+			blocks = append(blocks, b)
+
+		case quotePattern.MatchString(line):
+			var quoted []string
+			for ; i < len(lines) && quotePattern.MatchString(lines[i]); i++ {
+				quoted = append(quoted, quotePattern.FindStringSubmatch(lines[i])[1])
+			}
+			blocks = append(blocks, block{kind: blockQuote, children: parseBlocks(quoted)})
+
+		case listItemPattern.MatchString(line):
+			var b block
+			b, i = parseList(lines, i)
+			blocks = append(blocks, b)
+
+		default:
+			para := []string{strings.TrimSpace(line)}
+			for i++; i < len(lines) && !isBlank(lines[i]) && !startsBlock(lines[i]); i++ {
+				para = append(para, strings.TrimSpace(lines[i]))
+			}
+			blocks = append(blocks, block{kind: blockParagraph, text: strings.Join(para, "\n")})
+		}
+	}
+	return blocks
+}
+
+// parseList parses the list starting at line i. It returns the list and the index of the line following it.
+// Items are not nested: indented lines, and lines which do not start another block, continue the current item.
+func parseList(lines []string, i int) (block, int) {
+	m := listItemPattern.FindStringSubmatch(lines[i])
+	marker := m[1]
+	b := block{kind: blockList}
+	if last := marker[len(marker)-1]; last == '.' || last == ')' {
+		b.ordered = true
+		b.start, _ = strconv.Atoi(marker[:len(marker)-1])
+	}
+
+	var item []string
+	addItem := func() {
+		text := strings.Join(item, "\n")
+		task := -1
+		if t := taskPattern.FindStringSubmatch(text); t != nil {
+			task = 0
+			if t[1] != " " {
+				task = 1
+			}
+			text = text[len(t[0]):]
+		}
+		b.items = append(b.items, listItem{text: text, task: task})
+	}
+
+	for i < len(lines) {
+		line := lines[i]
+		if m := listItemPattern.FindStringSubmatch(line); m != nil && !isRule(line) {
+			if sameListKind(marker, m[1]) && !strings.HasPrefix(line, "  ") {
+				if item != nil {
+					addItem()
+				}
+				item = []string{strings.TrimSpace(m[2])}
+				i++
+				continue
+			}
+		}
+
+		if isBlank(line) {
+			// a blank line ends the list unless another item of it follows.
+			j := i
+			for j < len(lines) && isBlank(lines[j]) {
+				j++
+			}
+			if j < len(lines) {
+				if m := listItemPattern.FindStringSubmatch(lines[j]); m != nil && sameListKind(marker, m[1]) && !isRule(lines[j]) {
+					i = j
+					continue
+				}
+			}
+			break
+		}
+		if !strings.HasPrefix(line, "  ") && startsBlock(line) {
+			break
+		}
+		item = append(item, strings.TrimSpace(line))
+		i++
+	}
+	addItem()
+	return b, i
+}
+
+// sameListKind tells whether two list markers belong to the same list, e.g. - and - or 1. and 2.
+func sameListKind(a, b string) bool {
+	lastA, lastB := a[len(a)-1], b[len(b)-1]
+	orderedA := lastA == '.' || lastA == ')'
+	orderedB := lastB == '.' || lastB == ')'
+	if orderedA || orderedB {
+		return orderedA && orderedB && lastA == lastB
+	}
+	return a == b
+}
+
+// writeBlocks writes the HTML of given blocks, one per line.
+func writeBlocks(b *strings.Builder, blocks []block) {
+	for i, bl := range blocks {
+		if i > 0 {
+			b.WriteByte('\n')
+		}
+		switch bl.kind {
+		case blockParagraph:
+			b.WriteString("<p>")
+			writeInlines(b, parseInlines(bl.text))
+			b.WriteString("</p>")
+
+		case blockHeading:
+			level := strconv.Itoa(bl.level)
+			b.WriteString("<h" + level + ">")
+			writeInlines(b, parseInlines(bl.text))
+			b.WriteString("</h" + level + ">")
+
+		case blockCode:
+			b.WriteString("<pre><code")
+			if bl.lang != "" {
+				b.WriteString(` class="language-` + html.EscapeString(bl.lang) + `"`)
+			}
+			b.WriteString(">" + html.EscapeString(bl.text) + "</code></pre>")
+
+		case blockQuote:
+			b.WriteString("<blockquote>\n")
+			writeBlocks(b, bl.children)
+			b.WriteString("\n</blockquote>")
+
+		case blockList:
+			tag := "ul"
+			if bl.ordered {
+				tag = "ol"
+			}
+			b.WriteString("<" + tag)
+			if bl.ordered && bl.start != 1 {
+				b.WriteString(` start="` + strconv.Itoa(bl.start) + `"`)
+			}
+			b.WriteString(">\n")
+			for _, item := range bl.items {
+				b.WriteString("<li>")
+				switch item.task {
+				case 0:
+					b.WriteString(`<input type="checkbox" disabled> `)
+				case 1:
+					b.WriteString(`<input type="checkbox" checked disabled> `)
+				}
+				writeInlines(b, parseInlines(item.text))
+				b.WriteString("</li>\n")
+			}
+			b.WriteString("</" + tag + ">")
+
+		case blockRule:
+			b.WriteString("<hr>")
+		}
+	}
+}
+
+// textBlocks returns the plain text of given blocks, one entry per block.
+func textBlocks(blocks []block) []string {
+	var parts []string
+	for _, bl := range blocks {
+		switch bl.kind {
+		case blockParagraph, blockHeading:
+			parts = append(parts, inlinePlain(parseInlines(bl.text)))
+
+		case blockCode:
+			parts = append(parts, strings.TrimSuffix(bl.text, "\n"))
+
+		case blockQuote:
+			parts = append(parts, textBlocks(bl.children)...)
+
+		case blockList:
+			lines := make([]string, len(bl.items))
+			for i, item := range bl.items {
+				prefix := "- "
+				if bl.ordered {
+					prefix = strconv.Itoa(bl.start+i) + ". "
+				}
+				switch item.task {
+				case 0:
+					prefix += "[ ] "
+				case 1:
+					prefix += "[x] "
+				}
+				lines[i] = prefix + inlinePlain(parseInlines(item.text))
+			}
+			parts = append(parts, strings.Join(lines, "\n"))
+		}
+	}
+	return parts
+}
@@ -0,0 +1,269 @@
+package markdown
+
+import (
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTML(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		html string
+	}{
+		{"plain", "just text", "<p>just text</p>"},
+		{"emphasis", "**bold**, _em_, *em* and ~~gone~~ but not snake_case_name or 2 * 3",
+			"<p><strong>bold</strong>, <em>em</em>, <em>em</em> and <del>gone</del> but not snake_case_name or 2 * 3</p>"},
+		{"line-breaks", "one\ntwo\r\n\r\nthree", "<p>one<br>\ntwo</p>\n<p>three</p>"},
+		{"heading", "## Release notes ##", "<h2>Release notes</h2>"},
+		{"code-span", "run `a <b> **c**`", "<p>run <code>a &lt;b&gt; **c**</code></p>"},
+		{"code-block", "```go\nfmt.Println(\"<x>\")\n```", "<pre><code class=\"language-go\">fmt.Println(&#34;&lt;x&gt;&#34;)\n</code></pre>"},
+		{"quote", "> quoted\n> *text*", "<blockquote>\n<p>quoted<br>\n<em>text</em></p>\n</blockquote>"},
+		{"list", "- one\n- two", "<ul>\n<li>one</li>\n<li>two</li>\n</ul>"},
+		{"ordered-list", "3. three\n4. four", "<ol start=\"3\">\n<li>three</li>\n<li>four</li>\n</ol>"},
+		{"task-list", "- [ ] todo\n- [x] done", "<ul>\n<li><input type=\"checkbox\" disabled> todo</li>\n<li><input type=\"checkbox\" checked disabled> done</li>\n</ul>"},
+		{"rule", "above\n\n---", "<p>above</p>\n<hr>"},
+		{"link", "[the **docs**](https://github.com/docs)",
+			"<p><a href=\"https://github.com/docs\" rel=\"nofollow noopener noreferrer\">the <strong>docs</strong></a></p>"},
+		{"autolinks", "see <https://github.com> or www.github.com/x.",
+			"<p>see <a href=\"https://github.com\" rel=\"nofollow noopener noreferrer\">https://github.com</a> or " +
+				"<a href=\"http://www.github.com/x\" rel=\"nofollow noopener noreferrer\">www.github.com/x</a>.</p>"},
+		{"image", "![logo](https://github.com/logo.png \"Logo\")", "<p><img src=\"https://github.com/logo.png\" alt=\"logo\"></p>"},
+		{"escapes", `\*not em\* & "quoted"`, "<p>*not em* &amp; &#34;quoted&#34;</p>"},
+		// not supported, rendered as text.
+		{"nested-list", "- a\n  - b", "<ul>\n<li>a<br>\n- b</li>\n</ul>"},
+		{"table", "| a |\n|---|", "<p>| a |<br>\n|---|</p>"},
+		{"setext-heading", "Title\n=====", "<p>Title<br>\n=====</p>"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.html, HTML(tc.src))
+		})
+	}
+}
+
+func TestHTMLSanitized(t *testing.T) {
+	cases := map[string]string{
+		"script":          "<script>alert(1)</script>",
+		"event-handler":   `<img src=x onerror="alert(1)">`,
+		"javascript-link": "[click](javascript:alert(1))",
+		"mixed-case":      "[click](JaVaScRiPt:alert(1))",
+		"data-image":      "![x](data:image/svg+xml;base64,PHN2Zz4=)",
+		"mailto-image":    "![x](mailto:octo@github.com)",
+		"vbscript-auto":   "<vbscript:msgbox(1)>",
+		"quote-breakout":  `[x](https://github.com/"onmouseover="alert(1))`,
+		"code-lang":       "```\"><script>\nx\n```",
+	}
+	for name, src := range cases {
+		out := HTML(src)
+		assert.NotContains(t, out, "<script", name)
+		assert.NotContains(t, out, "<img src=x", name)
+		assert.NotContains(t, strings.ToLower(out), `href="javascript`, name)
+		assert.NotContains(t, out, `src="data:`, name)
+		assert.NotContains(t, out, `src="mailto:`, name)
+		assert.NotContains(t, out, `href="vbscript`, name)
+		assert.NotContains(t, out, `"onmouseover`, name)
+	}
+
+	assert.Equal(t, "<p>[click](javascript:alert(1))</p>", HTML("[click](javascript:alert(1))"))
+	assert.Equal(t, "<p>&lt;b onclick=&#34;x&#34;&gt;hi&lt;/b&gt;</p>", HTML(`<b onclick="x">hi</b>`))
+}
+
+// renderedTag matches every tag the renderer writes, with the attribute values it allows.
+var renderedTag = regexp.MustCompile(`^<(?:/?(?:p|h[1-6]|pre|blockquote|ul|ol|li|em|strong|del)|/code|/a|hr|br|` +
+	`code(?: class="language-[A-Za-z0-9_+#.-]+")?|ol start="\d+"|input type="checkbox"(?: checked)? disabled|` +
+	`a href="(?:https?://|mailto:)[^"'<>]*" rel="nofollow noopener noreferrer"|img src="https?://[^"'<>]*" alt="[^"'<>]*")>`)
+
+// assertSafe checks that every < of the output starts a tag written by the renderer,
+// and that the text between the tags is escaped.
+func assertSafe(t *testing.T, src, out string) {
+	for i := 0; i < len(out); {
+		j := strings.IndexAny(out[i:], "<>\"'")
+		if j < 0 {
+			return
+		}
+		i += j
+		tag := renderedTag.FindString(out[i:])
+		if tag == "" {
+			t.Errorf("unsafe HTML at offset %d of %q rendered from %q", i, out, src)
+			return
+		}
+		i += len(tag)
+	}
+}
+
+func TestHTMLUnsafeURLs(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		html string
+	}{
+		{"javascript-link", "[x](javascript:alert(1))", "<p>[x](javascript:alert(1))</p>"},
+		{"javascript-upper-case", "[x](JAVASCRIPT:alert(1))", "<p>[x](JAVASCRIPT:alert(1))</p>"},
+		{"javascript-tab", "[x](java\tscript:alert(1))", "<p>[x](java\tscript:alert(1))</p>"},
+		{"javascript-angle-brackets", "[x](<javascript:alert(1)>)", "<p>[x](&lt;javascript:alert(1)&gt;)</p>"},
+		{"vbscript-link", "[x](vbscript:msgbox(1))", "<p>[x](vbscript:msgbox(1))</p>"},
+		{"data-link", "[x](data:text/html,<script>alert(1)</script>)",
+			"<p>[x](data:text/html,&lt;script&gt;alert(1)&lt;/script&gt;)</p>"},
+		{"relative-link", "[x](//evil.com)", "<p>[x](//evil.com)</p>"},
+		{"hostless-link", "[x](https:alert)", "<p>[x](https:alert)</p>"},
+		{"javascript-image", "![x](javascript:alert(1))", "<p>![x](javascript:alert(1))</p>"},
+		{"vbscript-image", "![x](vbscript:msgbox(1))", "<p>![x](vbscript:msgbox(1))</p>"},
+		{"data-image", "![x](data:image/png;base64,AAAA)", "<p>![x](data:image/png;base64,AAAA)</p>"},
+		{"javascript-autolink", "<JavaScript:alert(1)>", "<p>&lt;JavaScript:alert(1)&gt;</p>"},
+		{"vbscript-autolink", "<vbscript:msgbox(1)>", "<p>&lt;vbscript:msgbox(1)&gt;</p>"},
+		{"data-autolink", "<data:text/html,x>", "<p>&lt;data:text/html,x&gt;</p>"},
+		{"mailto-link", "[x](mailto:octo@github.com)", "<p><a href=\"mailto:octo@github.com\" rel=\"nofollow noopener noreferrer\">x</a></p>"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := HTML(tc.src)
+			assert.Equal(t, tc.html, out)
+			assertSafe(t, tc.src, out)
+		})
+	}
+}
+
+func TestHTMLAttributeQuotes(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		html string
+	}{
+		{"double-quote-link", `[x](https://a.io/"onmouseover="alert(1))`,
+			`<p><a href="https://a.io/%22onmouseover=%22alert%281" rel="nofollow noopener noreferrer">x</a>)</p>`},
+		{"single-quote-link", `[x](https://a.io/'onmouseover='alert(1))`,
+			`<p><a href="https://a.io/&#39;onmouseover=&#39;alert(1" rel="nofollow noopener noreferrer">x</a>)</p>`},
+		{"title", `[x](https://a.io/" title="t")`, `<p><a href="https://a.io/%22" rel="nofollow noopener noreferrer">x</a></p>`},
+		{"angle-bracket-link", `[x](https://a.io/?q=<b>)`, `<p><a href="https://a.io/?q=&lt;b" rel="nofollow noopener noreferrer">x</a></p>`},
+		{"autolink", `<https://a.io/"onmouseover="x>`,
+			`<p><a href="https://a.io/%22onmouseover=%22x" rel="nofollow noopener noreferrer">https://a.io/&#34;onmouseover=&#34;x</a></p>`},
+		{"bare-link", `https://a.io/"onmouseover="x`,
+			`<p><a href="https://a.io/%22onmouseover=%22x" rel="nofollow noopener noreferrer">https://a.io/&#34;onmouseover=&#34;x</a></p>`},
+		{"bare-link-tag", `www.a.io/"><script>`,
+			`<p><a href="http://www.a.io/%22%3E" rel="nofollow noopener noreferrer">www.a.io/&#34;&gt;</a>&lt;script&gt;</p>`},
+		{"image-alt", `![x"onerror="alert(1)](https://a.io/a.png)`, `<p><img src="https://a.io/a.png" alt="x&#34;onerror=&#34;alert(1)"></p>`},
+		{"code-lang", "```js\" onclick=\"x\nx\n```", "<pre><code>x\n</code></pre>"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := HTML(tc.src)
+			assert.Equal(t, tc.html, out)
+			assertSafe(t, tc.src, out)
+		})
+	}
+}
+
+func TestHTMLRawHTML(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		html string
+	}{
+		{"paragraph", "<b>hi</b>", "<p>&lt;b&gt;hi&lt;/b&gt;</p>"},
+		{"heading", "# <b>hi</b>", "<h1>&lt;b&gt;hi&lt;/b&gt;</h1>"},
+		{"list", "- <b>hi</b>", "<ul>\n<li>&lt;b&gt;hi&lt;/b&gt;</li>\n</ul>"},
+		{"ordered-list", "1. <b>hi</b>", "<ol>\n<li>&lt;b&gt;hi&lt;/b&gt;</li>\n</ol>"},
+		{"task-list", "- [ ] <b>hi</b>", "<ul>\n<li><input type=\"checkbox\" disabled> &lt;b&gt;hi&lt;/b&gt;</li>\n</ul>"},
+		{"quote", "> <b>hi</b>", "<blockquote>\n<p>&lt;b&gt;hi&lt;/b&gt;</p>\n</blockquote>"},
+		{"code-block", "```\n<b>hi</b>\n```", "<pre><code>&lt;b&gt;hi&lt;/b&gt;\n</code></pre>"},
+		{"fence-info", "```<b>\nx\n```", "<pre><code>x\n</code></pre>"},
+		{"code-span", "`<b>hi</b>`", "<p><code>&lt;b&gt;hi&lt;/b&gt;</code></p>"},
+		{"emphasis", "**<b>hi</b>**", "<p><strong>&lt;b&gt;hi&lt;/b&gt;</strong></p>"},
+		{"link-text", "[<b>hi</b>](https://a.io)", `<p><a href="https://a.io" rel="nofollow noopener noreferrer">&lt;b&gt;hi&lt;/b&gt;</a></p>`},
+		{"image-alt", "![<b>hi</b>](https://a.io/a.png)", `<p><img src="https://a.io/a.png" alt="&lt;b&gt;hi&lt;/b&gt;"></p>`},
+		{"comment", "<!-- x -->", "<p>&lt;!-- x --&gt;</p>"},
+		{"event-handler", "<img src=x onerror=alert(1)>", "<p>&lt;img src=x onerror=alert(1)&gt;</p>"},
+		{"anchor", `<a href="javascript:x">y</a>`, "<p>&lt;a href=&#34;javascript:x&#34;&gt;y&lt;/a&gt;</p>"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := HTML(tc.src)
+			assert.Equal(t, tc.html, out)
+			assertSafe(t, tc.src, out)
+		})
+	}
+}
+
+func TestHTMLUnclosed(t *testing.T) {
+	cases := []struct {
+		name string
+		src  string
+		html string
+	}{
+		{"strong", "**bold", "<p>**bold</p>"},
+		{"strong-underscore", "__bold", "<p>__bold</p>"},
+		{"emphasis", "*em", "<p>*em</p>"},
+		{"emphasis-underscore", "_em", "<p>_em</p>"},
+		{"strikethrough", "~~gone", "<p>~~gone</p>"},
+		{"nested", "**a *b**", "<p><strong>a *b</strong></p>"},
+		{"nested-emphasis", "*a **b*", "<p><em>a **b</em></p>"},
+		{"strong-tag", "**<script>", "<p>**&lt;script&gt;</p>"},
+		{"code-span", "`code", "<p>`code</p>"},
+		{"code-span-run", "``code`", "<p>``code`</p>"},
+		{"code-span-tag", "`<script>", "<p>`&lt;script&gt;</p>"},
+		{"code-block", "```\n<script>", "<pre><code>&lt;script&gt;\n</code></pre>"},
+		{"link", "[<script>](https://a.io",
+			`<p>[&lt;script&gt;](<a href="https://a.io" rel="nofollow noopener noreferrer">https://a.io</a></p>`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out := HTML(tc.src)
+			assert.Equal(t, tc.html, out)
+			assertSafe(t, tc.src, out)
+		})
+	}
+}
+
+// fragments of Markdown syntax, raw HTML and URLs that random sources are made of.
+var fragments = []string{
+	"x", "text", " ", "\t", "\n", "\n\n", "\r\n", "\\", "&", "\"", "'", "<", ">", "<script>", "</p>", "<b onclick=\"x\">",
+	"*", "**", "_", "__", "~~", "`", "``", "```", "~~~", "[", "]", "(", ")", "![", "](", "[x](", "![x](",
+	"# ", "## ", "> ", "- ", "* ", "1. ", "2) ", "- [ ] ", "- [x] ", "---", "    ",
+	"javascript:", "JavaScript:", "vbscript:", "data:text/html,", "mailto:a@b.c", "https://a.io/", "http://", "www.a.io", "//a.io",
+	"onerror=", "=", "%22", "&#34;", "é", "\u2028",
+}
+
+// TestHTMLRandom renders random mixes of Markdown syntax, raw HTML and URLs, checking that the output is always safe.
+func TestHTMLRandom(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 20000; i++ {
+		var src strings.Builder
+		for n := rnd.Intn(24); n >= 0; n-- {
+			src.WriteString(fragments[rnd.Intn(len(fragments))])
+		}
+		assertSafe(t, src.String(), HTML(src.String()))
+		if t.Failed() {
+			return
+		}
+	}
+}
+
+// FuzzHTML checks that the output only ever contains the tags and attributes of renderedTag, whatever the source.
+// Run it with go test -fuzz=FuzzHTML ./comment/markdown/, without -fuzz only the seeds are checked.
+func FuzzHTML(f *testing.F) {
+	for _, s := range fragments {
+		f.Add(s)
+	}
+	f.Add("# Title\n\n> **quote** with `code`\n\n- [x] [link](https://a.io/?q=\"x\")\n1. ![img](http://a.io/i.png \"t\")\n\n```go\n<b>\n```")
+	f.Add("<https://a.io/x?a=1&b='2'> _em_ ~~del~~ <mailto:a@b.c>\n***\n")
+	f.Add("[x](https://github.com/\"onmouseover=\"alert(1)) ![x](javascript:alert(1)) <b onclick=\"x\">hi</b>")
+	f.Fuzz(func(t *testing.T, src string) {
+		out := HTML(src)
+		assertSafe(t, src, out)
+		if utf8.ValidString(src) && !utf8.ValidString(out) {
+			t.Errorf("invalid UTF-8 %q rendered from %q", out, src)
+		}
+	})
+}
+
+func TestText(t *testing.T) {
+	src := "# Title\n\nSee [the docs](https://github.com/docs), **now**.\n\n- [x] `done`\n- todo\n\n```\ncode <x>\n```"
+	assert.Equal(t, "Title\n\nSee the docs, now.\n\n- [x] done\n- todo\n\ncode <x>", Text(src))
+	assert.Equal(t, "", Text(""))
+}
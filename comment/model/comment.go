@@ -4,7 +4,142 @@ import "time"
 
 // Comment is a model for comment
 type Comment struct {
+	ID uint64 `json:"id"`
+	// Repo and Issue are only set for comments of a repository or of an issue of it.
+	Repo      string    `json:"repo,omitempty"`
+	Issue     int       `json:"issue,omitempty"`
 	Author    string    `json:"author"`
 	Comment   string    `json:"comment"`
+	Tags      []string  `json:"tags,omitempty"`
+	ParentID  uint64    `json:"parent_id,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Mentions are the lowercase logins of the org members mentioned as @login in the comment.
+	Mentions []string `json:"mentions,omitempty"`
+
+	// Score is the sum of the up (+1) and down (-1) votes on the comment.
+	Score int `json:"score"`
+	// Reactions counts the reactions to the comment by content, e.g. {"+1": 2, "heart": 1}.
+	Reactions map[string]int `json:"reactions,omitempty"`
+
+	// Hidden is set for comments hidden by moderation, which are left out of listings.
+	Hidden bool `json:"hidden,omitempty"`
+
+	// Pinned is set for comments pinned by an org admin, which lead the first page of listings.
+	Pinned bool `json:"pinned,omitempty"`
+
+	// DeletionBatch and DeletedBy are only set for soft-deleted comments.
+	DeletionBatch string `json:"deletion_batch,omitempty"`
+	DeletedBy     string `json:"deleted_by,omitempty"`
+
+	// ReplyCount and Replies are only set in the thread view.
+	ReplyCount *int       `json:"reply_count,omitempty"`
+	Replies    []*Comment `json:"replies,omitempty"`
+	// Deleted is only set for soft-deleted comments in the thread view, where they are kept as the parents
+	// of active replies with their author, text, tags, mentions and reactions left out, and in exports.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// CommentList is a model for a page of comments
+type CommentList struct {
+	Comments   []*Comment `json:"comments"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// SearchResult is a model for a comment matching a search
+type SearchResult struct {
+	Comment
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// SearchResultList is a model for the results of a search
+type SearchResultList struct {
+	Results []*SearchResult `json:"results"`
+}
+
+// Revision is a model for a prior text of an edited comment
+type Revision struct {
+	Comment    string    `json:"comment"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// RevisionList is a model for the edit history of a comment
+type RevisionList struct {
+	Revisions []*Revision `json:"revisions"`
+}
+
+// Deletion is a model for the result of a (soft) deletion
+type Deletion struct {
+	Message       string `json:"message"`
+	DeletionBatch string `json:"deletion_batch"`
+}
+
+// Restoration is a model for the result of restoring deleted comments
+type Restoration struct {
+	Restored int `json:"restored"`
+}
+
+// Purge is a model for the result of purging expired deleted comments
+type Purge struct {
+	Purged int `json:"purged"`
+}
+
+// Flag is a model for a report of a comment
+type Flag struct {
+	Flagger   string    `json:"flagger"`
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ModerationItem is a model for a flagged comment awaiting review
+type ModerationItem struct {
+	Comment
+	FlagCount int     `json:"flag_count"`
+	Flags     []*Flag `json:"flags"`
+}
+
+// ModerationQueue is a model for a page of flagged comments
+type ModerationQueue struct {
+	Items      []*ModerationItem `json:"items"`
+	NextCursor string            `json:"next_cursor,omitempty"`
+}
+
+// TagCount is a model for the usage of a tag
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// TagList is a model for the tags used in an org
+type TagList struct {
+	Tags []*TagCount `json:"tags"`
+}
+
+// Mention is a model for a comment mentioning a user
+type Mention struct {
+	Org string `json:"org"`
+	Comment
+}
+
+// MentionList is a model for a page of comments mentioning a user
+type MentionList struct {
+	Mentions   []*Mention `json:"mentions"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+}
+
+// ImportReport is a model for the result of a bulk import of comments
+type ImportReport struct {
+	Imported int `json:"imported"`
+	// Skipped counts the deleted comments of an export, which are not imported.
+	Skipped int            `json:"skipped,omitempty"`
+	Errors  []*ImportError `json:"errors"`
+}
+
+// ImportError is a model for a rejected row of a bulk import
+type ImportError struct {
+	Row     int    `json:"row"`
+	Message string `json:"message"`
 }
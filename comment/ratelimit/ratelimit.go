@@ -0,0 +1,127 @@
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// now is replaced by tests.
+var now = time.Now
+
+// Result describes the state of a bucket after a call to Allow.
+type Result struct {
+	// Allowed tells whether a token was taken.
+	Allowed bool
+	// Limit is the capacity of the bucket.
+	Limit int
+	// Remaining is the number of tokens left in the bucket.
+	Remaining int
+	// RetryAfter is how long to wait for the next token, zero if one is left.
+	RetryAfter time.Duration
+	// Reset is when the bucket will be full again.
+	Reset time.Time
+}
+
+// Limiter is an in-memory set of token buckets keyed by an arbitrary string.
+// Every bucket holds up to limit tokens and is refilled with limit tokens per window.
+type Limiter struct {
+	limit  int
+	window time.Duration
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+// bucket is the state of a single key.
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// New returns a limiter allowing limit calls per window and key. It allows everything if
+// limit or window is not positive.
+func New(limit int, window time.Duration) *Limiter {
+	return &Limiter{
+		limit:   limit,
+		window:  window,
+		buckets: map[string]*bucket{},
+	}
+}
+
+// Allow takes a token from the bucket of given key if there is one.
+func (l *Limiter) Allow(key string) Result {
+	if l.limit <= 0 || l.window <= 0 {
+		return Result{Allowed: true}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := now()
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(l.limit), last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = l.refill(b, now)
+	b.last = now
+
+	res := Result{Limit: l.limit}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = l.timeFor(1 - b.tokens)
+	}
+	res.Remaining = int(b.tokens)
+	res.Reset = now.Add(l.timeFor(float64(l.limit) - b.tokens))
+	return res
+}
+
+// Refund gives back a token taken by Allow for given key, e.g. for a call that turned out to be invalid.
+func (l *Limiter) Refund(key string) {
+	if l.limit <= 0 || l.window <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	b, ok := l.buckets[key]
+	if !ok {
+		return
+	}
+	b.tokens = math.Min(b.tokens+1, float64(l.limit))
+}
+
+// refill returns the tokens of given bucket at given time.
+func (l *Limiter) refill(b *bucket, now time.Time) float64 {
+	tokens := b.tokens + float64(now.Sub(b.last))/float64(l.window)*float64(l.limit)
+	if tokens > float64(l.limit) {
+		return float64(l.limit)
+	}
+	return tokens
+}
+
+// timeFor returns how long it takes to refill given number of tokens.
+func (l *Limiter) timeFor(tokens float64) time.Duration {
+	return time.Duration(tokens / float64(l.limit) * float64(l.window))
+}
+
+// sweep forgets the buckets that are full again, at most once per window, so that
+// the memory used is bounded by the keys seen within a window.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+	for key, b := range l.buckets {
+		if l.refill(b, now) >= float64(l.limit) {
+			delete(l.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAllow(t *testing.T) {
+	clock := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	defer func() { now = time.Now }()
+	l := New(2, time.Minute)
+
+	t.Run("burst", func(t *testing.T) {
+		res := l.Allow("alice")
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 2, res.Limit)
+		assert.Equal(t, 1, res.Remaining)
+		assert.Equal(t, clock.Add(30*time.Second), res.Reset)
+
+		res = l.Allow("alice")
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 0, res.Remaining)
+	})
+
+	t.Run("exhausted", func(t *testing.T) {
+		res := l.Allow("alice")
+		assert.False(t, res.Allowed)
+		assert.Equal(t, 0, res.Remaining)
+		assert.Equal(t, 30*time.Second, res.RetryAfter)
+		assert.Equal(t, clock.Add(time.Minute), res.Reset)
+	})
+
+	t.Run("other-key", func(t *testing.T) {
+		res := l.Allow("bob")
+		assert.True(t, res.Allowed)
+	})
+
+	t.Run("refill", func(t *testing.T) {
+		clock = clock.Add(30 * time.Second)
+		res := l.Allow("alice")
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 0, res.Remaining)
+	})
+
+	t.Run("refund", func(t *testing.T) {
+		l.Refund("alice")
+		res := l.Allow("alice")
+		assert.True(t, res.Allowed)
+		assert.Equal(t, 0, res.Remaining)
+
+		l.Refund("bob")
+		l.Refund("bob")
+		assert.Equal(t, 2.0, l.buckets["bob"].tokens)
+	})
+
+	t.Run("sweep", func(t *testing.T) {
+		clock = clock.Add(2 * time.Minute)
+		l.Allow("carol")
+		assert.Len(t, l.buckets, 1)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		l := New(0, time.Minute)
+		for i := 0; i < 10; i++ {
+			assert.True(t, l.Allow("alice").Allowed)
+		}
+	})
+}
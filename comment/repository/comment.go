@@ -2,6 +2,8 @@ package repository
 
 import (
 	context "context"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"log"
@@ -9,6 +11,7 @@ import (
 	"time"
 
 	"github.com/go-pg/pg"
+	"github.com/rahulbharuka/github-proxy/comment/markdown"
 	"github.com/rahulbharuka/github-proxy/comment/storage"
 )
 
@@ -19,24 +22,155 @@ var (
 
 	// ErrNoData ...
 	ErrNoData = errors.New("no comments for given org")
+
+	// ErrNotFound ...
+	ErrNotFound = errors.New("comment not found")
+
+	// ErrVersionMismatch is returned when a comment was changed since the version the write was based on.
+	ErrVersionMismatch = errors.New("comment was changed since the given version")
 )
 
 // Comment is a storage object for comment table.
 type Comment struct {
-	ID        uint64    `json:"id"`
-	Org       string    `json:"org"`
-	Author    string    `json:"author"`
-	Comment   string    `json:"comment"`
-	IsDeleted bool      `json:"is_deleted" pg:",use_zero"`
+	// skip columns maintained by the DB, e.g. search_vector.
+	tableName struct{} `pg:",discard_unknown_columns"`
+
+	ID  uint64 `json:"id"`
+	Org string `json:"org"`
+	// Repo and Issue scope the comment to a repository of the org or an issue of it, see Scope.
+	// They are taken from the URL, never from a request body.
+	Repo      string `json:"-"`
+	Issue     int    `json:"-"`
+	Author    string `json:"author"`
+	Comment   string `json:"comment"`
+	ParentID  uint64 `json:"parent_id"`
+	IsDeleted bool   `json:"-" pg:",use_zero"`
+	// CommentHTML caches the comment rendered as sanitized HTML, see markdown.HTML. It is never taken from a request body.
+	CommentHTML string `json:"-"`
+	// DeletionBatch is shared by all comments deleted by the same request.
+	// The deletion state is never taken from a request body.
+	DeletionBatch string `json:"-"`
+	DeletedBy     string `json:"-"`
+	// moderation state, see Flag and Review. It is never taken from a request body.
+	FlagCount  int       `json:"-" pg:",use_zero"`
+	IsHidden   bool      `json:"-" pg:",use_zero"`
+	ReviewedBy string    `json:"-"`
+	ReviewedAt time.Time `json:"-"`
+	// PinnedAt is set while an org admin pins the comment, see Pin.
+	PinnedAt time.Time `json:"-"`
+	// Score is the sum of the votes on the comment, see Vote. It is never taken from a request body.
+	Score int `json:"-"`
+	// Version is incremented by every change of the text or of the deletion state of the comment, see
+	// ErrVersionMismatch. Votes, reactions, pins and moderation leave it as it is.
+	Version   int       `json:"-"`
 	CreatedAt time.Time `json:"create_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+	// Tags are stored in the comment_tags table, see Tag.
+	Tags []string `json:"tags" sql:"-"`
+	// Reactions counts the reactions to the comment by content, see Reaction. It is never taken from a request body.
+	Reactions map[string]int `json:"-" sql:"-"`
+	// Mentions are the org members mentioned in the comment, see Mention. They are parsed from the comment text.
+	Mentions []string `json:"-" sql:"-"`
 }
 
 // String ...
 func (c Comment) String() string {
-	return fmt.Sprintf("Comment<%d %s %s %s %t %v %v>", c.ID, c.Org, c.Author, c.Comment, c.IsDeleted, c.CreatedAt, c.UpdatedAt)
+	return fmt.Sprintf("Comment<%d %s %s %d %s %s %d %t %s %s %d %t %v %d %d %v %v %v>", c.ID, c.Org, c.Repo, c.Issue, c.Author, c.Comment, c.ParentID, c.IsDeleted, c.DeletionBatch, c.DeletedBy, c.FlagCount, c.IsHidden, c.PinnedAt, c.Score, c.Version, c.CreatedAt, c.UpdatedAt, c.Tags)
+}
+
+// Scope is the place of a comment beneath its org: the whole org when empty, a repository
+// of the org when only Repo is set or an issue of the repository when Issue is set too.
+type Scope struct {
+	Repo  string
+	Issue int
+}
+
+// Cursor points at the last comment of a page in (created_at, id) order.
+// Ranked lists change their order with every vote and are paged by offset instead.
+type Cursor struct {
+	CreatedAt time.Time `json:"created_at"`
+	ID        uint64    `json:"id"`
+	Offset    int       `json:"offset,omitempty"`
+	// RankedAt is when a list ranked by RankHot was first fetched, see ListOptions.RankedAt.
+	RankedAt *time.Time `json:"ranked_at,omitempty"`
+}
+
+// rankings of ListAll besides creation time, see ListOptions.Rank.
+const (
+	// RankTop orders comments by score.
+	RankTop = "top"
+	// RankHot orders comments by score decayed with age, so that new comments get a chance to rise.
+	RankHot = "hot"
+)
+
+// hotRank is the SQL expression of the RankHot rank at a given time. The score of a comment is divided
+// by its age in hours plus two, to the power of 1.8.
+const hotRank = "score / power(greatest(extract(epoch from (?::timestamp - created_at)), 0) / 3600 + 2, 1.8)"
+
+// hasActiveReply is the SQL condition of a comment with an active reply beneath it, direct or nested.
+// Replies hidden by moderation do not count, nor do their own replies.
+const hasActiveReply = `EXISTS (
+	WITH RECURSIVE beneath AS (
+		SELECT id, is_deleted FROM comments WHERE parent_id = "comment"."id" AND is_hidden = false
+		UNION ALL
+		SELECT c.id, c.is_deleted FROM comments c JOIN beneath b ON c.parent_id = b.id WHERE c.is_hidden = false
+	)
+	SELECT 1 FROM beneath WHERE is_deleted = false)`
+
+// ListOptions narrows and pages the comments returned by ListAll.
+type ListOptions struct {
+	// Limit is the maximum number of comments returned. Zero means no limit.
+	Limit int
+	// After skips all comments up to and including the cursor.
+	After *Cursor
+	// RootsOnly skips replies. Unless Deleted is set, the soft-deleted comments with an active reply
+	// beneath them are listed too, so that the thread of the reply keeps its root.
+	RootsOnly bool
+	// Scope lists the comments in the scope and beneath it, e.g. a repository along with its issues.
+	Scope Scope
+	// Author lists only comments of the author, regardless of case.
+	Author string
+	// Tag lists only comments carrying the tag.
+	Tag string
+	// Since and Until bound the creation time of listed comments when not zero.
+	Since time.Time
+	Until time.Time
+	// Descending lists newest comments first.
+	Descending bool
+	// Rank orders the comments by RankTop or RankHot instead of creation time, best first, and ignores
+	// Descending and After. Comments ranking the same are ordered newest first.
+	Rank string
+	// RankedAt is the time the ages of comments are computed at for RankHot. It must stay the same
+	// for all pages, so that their order does not change as time goes by.
+	RankedAt time.Time
+	// Offset skips the first comments of a ranked list.
+	Offset int
+	// Deleted lists soft-deleted comments instead of active ones.
+	Deleted bool
+	// Flagged lists only active comments with pending flags. Active comments hidden by
+	// moderation are skipped unless Flagged is set.
+	Flagged bool
+	// PinnedFirst lists the pinned comments, most recently pinned first, ahead of the first page
+	// (After unset) on top of Limit. They are left out of the rest of the list.
+	PinnedFirst bool
+}
+
+// RestoreOptions selects the soft-deleted comments to restore.
+// All deleted comments of the org are restored when both are empty.
+type RestoreOptions struct {
+	IDs           []uint64
+	DeletionBatch string
+}
+
+// ExportOptions selects the comments streamed by Export.
+type ExportOptions struct {
+	// All includes soft-deleted and hidden comments.
+	All bool
 }
 
+// importBatchSize is the number of comments inserted by one statement of Import.
+const importBatchSize = 500
+
 // commentRepoImpl ...
 type commentRepoImpl struct {
 	db *pg.DB
@@ -45,9 +179,34 @@ type commentRepoImpl struct {
 // CommentRepo implements following methods.
 // go:generate mockery -inpkg -case underscore -name CommentRepo
 type CommentRepo interface {
-	ListAll(ctx context.Context, org string) ([]Comment, error)
+	ListAll(ctx context.Context, org string, opts ListOptions) ([]Comment, error)
+	ListReplies(ctx context.Context, org string, rootIDs []uint64) ([]Comment, error)
+	Search(ctx context.Context, org string, opts SearchOptions) ([]SearchResult, error)
+	ListRevisions(ctx context.Context, commentID uint64) ([]Revision, error)
+	Get(ctx context.Context, org string, id uint64) (*Comment, error)
+	HasDuplicate(ctx context.Context, org, author, comment string, since time.Time) (bool, error)
 	Save(ctx context.Context, c *Comment) error
-	DeleteAll(ctx context.Context, org string) error
+	Update(ctx context.Context, c *Comment) error
+	Delete(ctx context.Context, org string, id uint64, version int, deletedBy string) (string, error)
+	DeleteAll(ctx context.Context, org string, deletedBy string) (string, error)
+	Restore(ctx context.Context, org string, opts RestoreOptions) (int, error)
+	Purge(ctx context.Context, deletedBefore time.Time, limit int) (int, error)
+	Export(ctx context.Context, org string, opts ExportOptions, fn func(*Comment) error) error
+	Import(ctx context.Context, comments []Comment) error
+	TagCounts(ctx context.Context, org string) ([]TagCount, error)
+	Flag(ctx context.Context, org string, f *Flag, threshold int) (*Comment, error)
+	ListFlags(ctx context.Context, commentIDs []uint64) ([]Flag, error)
+	Review(ctx context.Context, org string, id uint64, hide bool, reviewer string) (*Comment, error)
+	Pin(ctx context.Context, org string, id uint64, maxPinned int) (*Comment, error)
+	Unpin(ctx context.Context, org string, id uint64) (*Comment, error)
+	React(ctx context.Context, org string, reaction *Reaction) (bool, error)
+	Unreact(ctx context.Context, org string, reaction *Reaction) error
+	Vote(ctx context.Context, org string, v *Vote) error
+	ListMentions(ctx context.Context, login string, limit int, after *Cursor) ([]Comment, error)
+	ClaimIdempotencyKey(ctx context.Context, resp *IdempotentResponse) (*IdempotentResponse, error)
+	CompleteIdempotencyKey(ctx context.Context, resp *IdempotentResponse) error
+	ReleaseIdempotencyKey(ctx context.Context, resp *IdempotentResponse) error
+	PurgeIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (int, error)
 }
 
 // NewCommentRepo returns the CommentRepo handler.
@@ -60,10 +219,81 @@ func NewCommentRepo() CommentRepo {
 	return singletonCommentRepo
 }
 
-// ListAll lists all comments
-func (r *commentRepoImpl) ListAll(ctx context.Context, org string) ([]Comment, error) {
-	var comments []Comment
-	err := r.db.Model(&comments).Where("org=? and is_deleted=?", org, false).Select()
+// ListAll lists comments of given org ordered by (created_at, id).
+func (r *commentRepoImpl) ListAll(ctx context.Context, org string, opts ListOptions) ([]Comment, error) {
+	var comments, pinned []Comment
+	q := r.db.Model(&comments).Where("org=?", org)
+	if opts.RootsOnly && !opts.Deleted {
+		q = q.Where("(is_deleted=? or "+hasActiveReply+")", false)
+	} else {
+		q = q.Where("is_deleted=?", opts.Deleted)
+	}
+	if opts.Flagged {
+		q = q.Where("flag_count>0")
+	} else if !opts.Deleted {
+		q = q.Where("is_hidden=?", false)
+	}
+	if opts.RootsOnly {
+		q = q.Where("parent_id IS NULL")
+	}
+	if opts.Scope.Repo != "" {
+		q = q.Where("repo=?", opts.Scope.Repo)
+	}
+	if opts.Scope.Issue != 0 {
+		q = q.Where("issue=?", opts.Scope.Issue)
+	}
+	if opts.Author != "" {
+		q = q.Where("lower(author)=lower(?)", opts.Author)
+	}
+	if opts.Tag != "" {
+		q = q.Where("id IN (SELECT comment_id FROM comment_tags WHERE org=? AND tag=?)", org, opts.Tag)
+	}
+	if !opts.Since.IsZero() {
+		q = q.Where("created_at>=?", opts.Since)
+	}
+	if !opts.Until.IsZero() {
+		q = q.Where("created_at<?", opts.Until)
+	}
+	if opts.PinnedFirst {
+		if opts.After == nil && opts.Offset == 0 {
+			err := q.Copy().Where("pinned_at IS NOT NULL").Order("pinned_at DESC", "id DESC").Select(&pinned)
+			if err != nil {
+				log.Printf("ERROR: failed to list pinned comments for org %v, err: %v", org, err)
+				return nil, err
+			}
+		}
+		q = q.Where("pinned_at IS NULL")
+	}
+	if opts.After != nil && opts.Rank == "" {
+		if opts.Descending {
+			q = q.Where("(created_at, id) < (?, ?)", opts.After.CreatedAt, opts.After.ID)
+		} else {
+			q = q.Where("(created_at, id) > (?, ?)", opts.After.CreatedAt, opts.After.ID)
+		}
+	}
+	if opts.Limit > 0 {
+		q = q.Limit(opts.Limit)
+	}
+
+	if opts.Offset > 0 {
+		q = q.Offset(opts.Offset)
+	}
+
+	switch {
+	case opts.Rank == RankTop:
+		q = q.Order("score DESC", "created_at DESC", "id DESC")
+	case opts.Rank == RankHot:
+		q = q.OrderExpr(hotRank+" DESC, created_at DESC, id DESC", opts.RankedAt)
+	case opts.Descending:
+		q = q.Order("created_at DESC", "id DESC")
+	default:
+		q = q.Order("created_at ASC", "id ASC")
+	}
+	err := q.Select()
+	if err == nil {
+		comments = append(pinned, comments...)
+		err = r.loadRelations(comments)
+	}
 	if err != nil {
 		log.Printf("ERROR: failed to list comments for org %v, err: %v", org, err)
 		return nil, err
@@ -72,13 +302,122 @@ func (r *commentRepoImpl) ListAll(ctx context.Context, org string) ([]Comment, e
 	return comments, nil
 }
 
-// Save saves the comment in table.
+// ListReplies lists all replies, direct or nested, to given comments ordered by (created_at, id).
+// Soft-deleted replies are listed too, so that the active replies beneath them keep their parent.
+// Replies hidden by moderation are skipped along with their own replies.
+func (r *commentRepoImpl) ListReplies(ctx context.Context, org string, rootIDs []uint64) ([]Comment, error) {
+	var comments []Comment
+	if len(rootIDs) == 0 {
+		return comments, nil
+	}
+
+	_, err := r.db.Query(&comments, `
+		WITH RECURSIVE thread AS (
+			SELECT * FROM comments WHERE org = ? AND parent_id IN (?) AND is_hidden = ?
+			UNION ALL
+			SELECT c.* FROM comments c JOIN thread t ON c.parent_id = t.id WHERE c.is_hidden = ?
+		)
+		SELECT * FROM thread ORDER BY created_at ASC, id ASC`, org, pg.In(rootIDs), false, false)
+	if err == nil {
+		err = r.loadRelations(comments)
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to list replies for org %v, err: %v", org, err)
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// Export streams the comments of given org in (created_at, id) order to fn, one row at a time
+// as they are read from the DB. It stops at the first error returned by fn.
+func (r *commentRepoImpl) Export(ctx context.Context, org string, opts ExportOptions, fn func(*Comment) error) error {
+	q := r.db.Model((*Comment)(nil)).Where("org=?", org)
+	if !opts.All {
+		q = q.Where("is_deleted=? and is_hidden=?", false, false)
+	}
+
+	err := q.Order("created_at ASC", "id ASC").ForEach(fn)
+	if err != nil {
+		log.Printf("ERROR: failed to export comments for org %v, err: %v", org, err)
+		return err
+	}
+	return nil
+}
+
+// Import inserts given comments as they are, timestamps included, in batches of importBatchSize
+// within one transaction, so that either all or none of them are saved. The IDs are set on success.
+func (r *commentRepoImpl) Import(ctx context.Context, comments []Comment) error {
+	for i := range comments {
+		comments[i].CommentHTML = markdown.HTML(comments[i].Comment)
+	}
+
+	err := r.db.RunInTransaction(func(tx *pg.Tx) error {
+		for start := 0; start < len(comments); start += importBatchSize {
+			end := start + importBatchSize
+			if end > len(comments) {
+				end = len(comments)
+			}
+			batch := comments[start:end]
+			if _, err := tx.Model(&batch).Insert(); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	if err != nil {
+		log.Printf("ERROR: failed to import %v comments, err: %v", len(comments), err)
+	}
+	return err
+}
+
+// Get fetches a single active comment of given org, even if it is hidden by moderation, see Comment.IsHidden.
+func (r *commentRepoImpl) Get(ctx context.Context, org string, id uint64) (*Comment, error) {
+	comments := make([]Comment, 1)
+	err := r.db.Model(&comments[0]).Where("id=? and org=? and is_deleted=?", id, org, false).Select()
+	if err == pg.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err == nil {
+		err = r.loadRelations(comments)
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to get comment %v for org %v, err: %v", id, org, err)
+		return nil, err
+	}
+
+	return &comments[0], nil
+}
+
+// HasDuplicate tells whether the author posted an active comment with the same text to given org since given time.
+func (r *commentRepoImpl) HasDuplicate(ctx context.Context, org, author, comment string, since time.Time) (bool, error) {
+	exists, err := r.db.Model((*Comment)(nil)).
+		Where("org=? and author=? and created_at>=? and comment=? and is_deleted=?", org, author, since, comment, false).
+		Exists()
+	if err != nil {
+		log.Printf("ERROR: failed to look up duplicates of comment by %v for org %v, err: %v", author, org, err)
+		return false, err
+	}
+	return exists, nil
+}
+
+// Save saves the comment in table along with its tags and mentions.
 func (r *commentRepoImpl) Save(ctx context.Context, c *Comment) error {
 	currentTime := time.Now()
 	c.CreatedAt = currentTime
 	c.UpdatedAt = currentTime
+	c.CommentHTML = markdown.HTML(c.Comment)
 
-	err := r.db.Insert(c)
+	err := r.db.RunInTransaction(func(tx *pg.Tx) error {
+		if err := tx.Insert(c); err != nil {
+			return err
+		}
+		if err := saveTags(tx, c); err != nil {
+			return err
+		}
+		return saveMentions(tx, c)
+	})
 	if err != nil {
 		log.Printf("ERROR: failed to save comment %+v, err: %v", c, err)
 		return err
@@ -86,25 +425,202 @@ func (r *commentRepoImpl) Save(ctx context.Context, c *Comment) error {
 	return nil
 }
 
-// DeleteAll marks all record for given org as deleted.
-func (r *commentRepoImpl) DeleteAll(ctx context.Context, org string) error {
+// Update updates the text of an active comment at version c.Version and refreshes c with the stored row.
+// The replaced text is kept as a revision, and the mentions are replaced by c.Mentions, in the same transaction.
+func (r *commentRepoImpl) Update(ctx context.Context, c *Comment) error {
+	err := r.db.RunInTransaction(func(tx *pg.Tx) error {
+		old := &Comment{}
+		err := tx.Model(old).Where("id=? and org=? and is_deleted=?", c.ID, c.Org, false).For("UPDATE").Select()
+		if err == pg.ErrNoRows {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if old.Version != c.Version {
+			return ErrVersionMismatch
+		}
+
+		c.UpdatedAt = time.Now()
+		c.CommentHTML = markdown.HTML(c.Comment)
+		revision := &Revision{
+			CommentID:  old.ID,
+			Comment:    old.Comment,
+			CreatedAt:  old.UpdatedAt,
+			ReplacedAt: c.UpdatedAt,
+		}
+		if err := tx.Insert(revision); err != nil {
+			return err
+		}
+
+		_, err = tx.Model(c).Set("comment=?comment, comment_html=?comment_html, updated_at=?updated_at, version=version+1").WherePK().Returning("*").Update()
+		if err != nil {
+			return err
+		}
+		return replaceMentions(tx, c)
+	})
+
+	if err != nil && err != ErrNotFound && err != ErrVersionMismatch {
+		log.Printf("ERROR: failed to update comment %+v, err: %v", c, err)
+	}
+	return err
+}
+
+// Delete marks a single comment of given org at given version as deleted by given user and returns its deletion batch.
+// A deleted comment is no longer pinned.
+func (r *commentRepoImpl) Delete(ctx context.Context, org string, id uint64, version int, deletedBy string) (string, error) {
+	c := &Comment{
+		ID:            id,
+		Org:           org,
+		IsDeleted:     true,
+		DeletionBatch: newDeletionBatch(),
+		DeletedBy:     deletedBy,
+		UpdatedAt:     time.Now(),
+	}
+	resp, err := r.db.Model(c).
+		Set("is_deleted=?is_deleted, deletion_batch=?deletion_batch, deleted_by=?deleted_by, updated_at=?updated_at, pinned_at=NULL, version=version+1").
+		Where("id=?id and org=?org and is_deleted=? and version=?", false, version).
+		Update()
+	if err != nil {
+		log.Printf("ERROR: failed to delete comment %v for org %v, err: %v", id, org, err)
+		return "", err
+	}
+
+	if resp.RowsAffected() <= 0 {
+		// tell a comment changed by a concurrent writer from a missing one.
+		exists, err := r.db.Model((*Comment)(nil)).Where("id=? and org=? and is_deleted=?", id, org, false).Exists()
+		if err != nil {
+			log.Printf("ERROR: failed to check comment %v for org %v, err: %v", id, org, err)
+			return "", err
+		}
+		if exists {
+			return "", ErrVersionMismatch
+		}
+		return "", ErrNotFound
+	}
+	return c.DeletionBatch, nil
+}
+
+// DeleteAll marks all record for given org as deleted by given user and returns their deletion batch.
+func (r *commentRepoImpl) DeleteAll(ctx context.Context, org string, deletedBy string) (string, error) {
 	c := &Comment{
-		Org:       org,
-		IsDeleted: true,
-		UpdatedAt: time.Now(),
+		Org:           org,
+		IsDeleted:     true,
+		DeletionBatch: newDeletionBatch(),
+		DeletedBy:     deletedBy,
+		UpdatedAt:     time.Now(),
 	}
-	resp, err := r.db.Model(c).Set("is_deleted=?is_deleted, updated_at=?updated_at").Where("org=?org and is_deleted=?", false).Update()
+	resp, err := r.db.Model(c).Set("is_deleted=?is_deleted, deletion_batch=?deletion_batch, deleted_by=?deleted_by, updated_at=?updated_at, pinned_at=NULL, version=version+1").Where("org=?org and is_deleted=?", false).Update()
 
 	if err != nil {
 		log.Printf("ERROR: failed to delete comments for org %v, err: %v", org, err)
-		return err
+		return "", err
 	}
 
 	rowsAffected := resp.RowsAffected()
 	if rowsAffected <= 0 {
 		log.Printf("INFO: %v rows affected while deleting comments for org %v, err: %v", rowsAffected, org, err)
-		return ErrNoData
+		return "", ErrNoData
 	}
 
+	return c.DeletionBatch, nil
+}
+
+// Restore marks soft-deleted comments of given org as active again and returns how many were restored.
+func (r *commentRepoImpl) Restore(ctx context.Context, org string, opts RestoreOptions) (int, error) {
+	q := r.db.Model((*Comment)(nil)).
+		Set("is_deleted=?, deletion_batch=NULL, deleted_by=NULL, updated_at=?, version=version+1", false, time.Now()).
+		Where("org=? and is_deleted=?", org, true)
+	if len(opts.IDs) > 0 {
+		q = q.Where("id IN (?)", pg.In(opts.IDs))
+	}
+	if opts.DeletionBatch != "" {
+		q = q.Where("deletion_batch=?", opts.DeletionBatch)
+	}
+
+	resp, err := q.Update()
+	if err != nil {
+		log.Printf("ERROR: failed to restore comments for org %v, err: %v", org, err)
+		return 0, err
+	}
+
+	rowsAffected := resp.RowsAffected()
+	if rowsAffected <= 0 {
+		log.Printf("INFO: %v rows affected while restoring comments for org %v", rowsAffected, org)
+		return 0, ErrNoData
+	}
+
+	return rowsAffected, nil
+}
+
+// Purge permanently deletes up to limit comments that were soft-deleted before given time
+// and returns how many were purged. Their revisions are removed along with them.
+// Comments with replies are kept, so that no reply loses its parent: a thread is purged
+// from its leaves up, its comments with replies in a later batch than their replies.
+func (r *commentRepoImpl) Purge(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	resp, err := r.db.Exec(`
+		DELETE FROM comments WHERE id IN (
+			SELECT c.id FROM comments c
+			WHERE c.is_deleted = ? AND c.updated_at < ?
+			AND NOT EXISTS (SELECT 1 FROM comments r WHERE r.parent_id = c.id)
+			ORDER BY c.id LIMIT ?
+		)`, true, deletedBefore, limit)
+	if err != nil {
+		log.Printf("ERROR: failed to purge comments deleted before %v, err: %v", deletedBefore, err)
+		return 0, err
+	}
+
+	return resp.RowsAffected(), nil
+}
+
+// loadRelations sets the tags, reaction counts and mentions of given comments, and the HTML of those stored before it was cached.
+func (r *commentRepoImpl) loadRelations(comments []Comment) error {
+	if err := r.cacheHTML(comments); err != nil {
+		return err
+	}
+	if err := r.loadTags(comments); err != nil {
+		return err
+	}
+	if err := r.loadReactions(comments); err != nil {
+		return err
+	}
+	return r.loadMentions(comments)
+}
+
+// cacheHTML renders the HTML of given comments stored before it was cached, see CommentHTML, and stores it,
+// so that every comment is rendered once. An edit in the meantime stores its own HTML and is left as it is.
+func (r *commentRepoImpl) cacheHTML(comments []Comment) error {
+	for i := range comments {
+		c := &comments[i]
+		if c.CommentHTML != "" {
+			continue
+		}
+		c.CommentHTML = markdown.HTML(c.Comment)
+		_, err := r.db.Model(c).Set("comment_html=?comment_html").Where("id=?id and comment=?comment and comment_html=''").Update()
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// indexComments returns the IDs of given comments along with their positions by ID.
+func indexComments(comments []Comment) ([]uint64, map[uint64]int) {
+	ids := make([]uint64, len(comments))
+	index := make(map[uint64]int, len(comments))
+	for i := range comments {
+		ids[i] = comments[i].ID
+		index[comments[i].ID] = i
+	}
+	return ids, index
+}
+
+// newDeletionBatch returns a random identifier for a deletion batch.
+func newDeletionBatch() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		// fall back to a time based batch, it only needs to be unique per org.
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
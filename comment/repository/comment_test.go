@@ -1,6 +1,8 @@
 package repository
 
 import (
+	"context"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -11,3 +13,29 @@ func TestNewCommentRepo(t *testing.T) {
 	h2 := NewCommentRepo()
 	assert.Equal(t, h1, h2)
 }
+
+func TestGetCachesHTML(t *testing.T) {
+	// the comment was stored before its HTML was cached.
+	r, fake := newFakeRepo(t, func(query string) fakeResult {
+		if strings.HasPrefix(query, `SELECT "comment"."id"`) {
+			return fakeResult{columns: []string{"id", "org", "comment", "comment_html"}, rows: [][]string{{"1", "github", "_old_", ""}}, tag: "SELECT 1"}
+		}
+		if strings.HasPrefix(query, "SELECT") {
+			return fakeResult{tag: "SELECT 0"}
+		}
+		return fakeResult{tag: "UPDATE 1"}
+	})
+	defer fake.Close()
+
+	c, err := r.Get(context.Background(), "github", 1)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p><em>old</em></p>", c.CommentHTML)
+
+	var updates []string
+	for _, q := range fake.Queries() {
+		if strings.HasPrefix(q, "UPDATE") {
+			updates = append(updates, q)
+		}
+	}
+	assert.Len(t, updates, 1)
+}
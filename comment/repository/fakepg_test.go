@@ -0,0 +1,171 @@
+package repository
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-pg/pg"
+)
+
+// fakeResult is the answer of the fake server to a query: the rows of given columns, all sent as text, and the command tag.
+type fakeResult struct {
+	columns []string
+	rows    [][]string
+	tag     string
+}
+
+// fakePG is a Postgres server speaking just enough of the wire protocol to run the queries of the repository.
+// Every query is recorded and answered by respond. It tests how the repository handles the answers of the DB,
+// e.g. missing rows, the queries themselves are run against PostgreSQL by the tests of integration_test.go.
+type fakePG struct {
+	respond func(query string) fakeResult
+	ln      net.Listener
+	db      *pg.DB
+
+	mu      sync.Mutex
+	queries []string
+}
+
+// newFakeRepo returns a repository connected to a fake server answering queries with respond.
+// Transaction statements are answered by the server itself. The server must be closed after use.
+func newFakeRepo(t *testing.T, respond func(query string) fakeResult) (*commentRepoImpl, *fakePG) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fake := &fakePG{respond: respond, ln: ln}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go fake.serve(conn)
+		}
+	}()
+
+	fake.db = pg.Connect(&pg.Options{Addr: ln.Addr().String(), User: "test", MaxRetries: 0})
+	return &commentRepoImpl{db: fake.db}, fake
+}
+
+// Close disconnects the repository and stops the server.
+func (f *fakePG) Close() {
+	_ = f.db.Close()
+	_ = f.ln.Close()
+}
+
+// Queries returns the queries received so far.
+func (f *fakePG) Queries() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.queries...)
+}
+
+func (f *fakePG) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+
+	// the startup message has no type byte, any user is trusted.
+	if _, err := readMessage(r); err != nil {
+		return
+	}
+	writeMessage(w, 'R', []byte{0, 0, 0, 0})
+	writeMessage(w, 'Z', []byte{'I'})
+	w.Flush()
+
+	for {
+		typ, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		body, err := readMessage(r)
+		if err != nil || typ == 'X' {
+			return
+		}
+		if typ != 'Q' {
+			writeMessage(w, 'E', []byte("SERROR\x00C0A000\x00Monly simple queries are supported\x00\x00"))
+			writeMessage(w, 'Z', []byte{'I'})
+			w.Flush()
+			continue
+		}
+
+		query := strings.TrimSpace(strings.TrimSuffix(string(body), "\x00"))
+		f.mu.Lock()
+		f.queries = append(f.queries, query)
+		f.mu.Unlock()
+
+		var res fakeResult
+		switch verb := strings.ToUpper(strings.Fields(query + " ")[0]); verb {
+		case "BEGIN", "COMMIT", "ROLLBACK":
+			res.tag = verb
+		default:
+			res = f.respond(query)
+		}
+		if res.columns != nil {
+			writeMessage(w, 'T', rowDescription(res.columns))
+			for _, row := range res.rows {
+				writeMessage(w, 'D', dataRow(row))
+			}
+		}
+		writeMessage(w, 'C', append([]byte(res.tag), 0))
+		writeMessage(w, 'Z', []byte{'I'})
+		w.Flush()
+	}
+}
+
+// readMessage reads the body of a message following its type byte.
+func readMessage(r *bufio.Reader) ([]byte, error) {
+	var size [4]byte
+	if _, err := io.ReadFull(r, size[:]); err != nil {
+		return nil, err
+	}
+	body := make([]byte, binary.BigEndian.Uint32(size[:])-4)
+	_, err := io.ReadFull(r, body)
+	return body, err
+}
+
+func writeMessage(w *bufio.Writer, typ byte, body []byte) {
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(body)+4))
+	w.WriteByte(typ)
+	w.Write(size[:])
+	w.Write(body)
+}
+
+// rowDescription describes text columns of given names.
+func rowDescription(columns []string) []byte {
+	b := appendUint16(nil, uint16(len(columns)))
+	for _, name := range columns {
+		b = append(append(b, name...), 0)
+		b = appendUint32(b, 0)          // table oid
+		b = appendUint16(b, 0)          // column number
+		b = appendUint32(b, 25)         // text oid
+		b = appendUint16(b, 0xffff)     // variable length
+		b = appendUint32(b, 0xffffffff) // no type modifier
+		b = appendUint16(b, 0)          // text format
+	}
+	return b
+}
+
+func dataRow(values []string) []byte {
+	b := appendUint16(nil, uint16(len(values)))
+	for _, v := range values {
+		b = appendUint32(b, uint32(len(v)))
+		b = append(b, v...)
+	}
+	return b
+}
+
+func appendUint16(b []byte, v uint16) []byte {
+	return append(b, byte(v>>8), byte(v))
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+}
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	context "context"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// IdempotentResponse is a storage object for idempotent_responses table.
+// It holds the response to the first request made with an idempotency key, so that retries of
+// the request get the same response. Status is zero while the first request is in progress.
+type IdempotentResponse struct {
+	tableName struct{} `sql:"idempotent_responses"`
+
+	IdempotencyKey string    `json:"idempotency_key" sql:",pk"`
+	Org            string    `json:"org" sql:",pk"`
+	Author         string    `json:"author" sql:",pk"`
+	RequestHash    string    `json:"request_hash"`
+	Status         int       `json:"status" pg:",use_zero"`
+	Body           []byte    `json:"body"`
+	CreatedAt      time.Time `json:"created_at"`
+	ExpiresAt      time.Time `json:"expires_at"`
+	// Headers are the headers of the response replayed along with its body.
+	Headers http.Header `json:"headers"`
+}
+
+// String ...
+func (r IdempotentResponse) String() string {
+	return fmt.Sprintf("IdempotentResponse<%s %s %s %s %d %v %v>", r.IdempotencyKey, r.Org, r.Author, r.RequestHash, r.Status, r.CreatedAt, r.ExpiresAt)
+}
+
+// ClaimIdempotencyKey stores given pending response unless an unexpired response with the same
+// key, org and author exists, which is returned instead. An expired response is replaced.
+func (r *commentRepoImpl) ClaimIdempotencyKey(ctx context.Context, resp *IdempotentResponse) (*IdempotentResponse, error) {
+	res, err := r.db.Model(resp).
+		OnConflict("(idempotency_key, org, author) DO UPDATE").
+		Set("request_hash=EXCLUDED.request_hash, status=EXCLUDED.status, body=EXCLUDED.body, headers=EXCLUDED.headers, created_at=EXCLUDED.created_at, expires_at=EXCLUDED.expires_at").
+		Where("idempotent_response.expires_at<=?", resp.CreatedAt).
+		Insert()
+	if err != nil {
+		log.Printf("ERROR: failed to claim idempotency key %+v, err: %v", resp, err)
+		return nil, err
+	}
+	if res.RowsAffected() > 0 {
+		return nil, nil
+	}
+
+	existing := &IdempotentResponse{IdempotencyKey: resp.IdempotencyKey, Org: resp.Org, Author: resp.Author}
+	err = r.db.Model(existing).WherePK().Select()
+	if err != nil {
+		log.Printf("ERROR: failed to get idempotency key %+v, err: %v", resp, err)
+		return nil, err
+	}
+	return existing, nil
+}
+
+// CompleteIdempotencyKey stores the status, body and headers of a response claimed by ClaimIdempotencyKey.
+func (r *commentRepoImpl) CompleteIdempotencyKey(ctx context.Context, resp *IdempotentResponse) error {
+	_, err := r.db.Model(resp).Set("status=?status, body=?body, headers=?headers").WherePK().Update()
+	if err != nil {
+		log.Printf("ERROR: failed to complete idempotency key %+v, err: %v", resp, err)
+	}
+	return err
+}
+
+// ReleaseIdempotencyKey deletes a response claimed by ClaimIdempotencyKey, so that the key can be used again.
+func (r *commentRepoImpl) ReleaseIdempotencyKey(ctx context.Context, resp *IdempotentResponse) error {
+	_, err := r.db.Model(resp).WherePK().Delete()
+	if err != nil {
+		log.Printf("ERROR: failed to release idempotency key %+v, err: %v", resp, err)
+	}
+	return err
+}
+
+// PurgeIdempotencyKeys deletes the responses expired before given time and returns how many were deleted.
+func (r *commentRepoImpl) PurgeIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (int, error) {
+	res, err := r.db.Model((*IdempotentResponse)(nil)).Where("expires_at<?", expiredBefore).Delete()
+	if err != nil {
+		log.Printf("ERROR: failed to purge idempotency keys expired before %v, err: %v", expiredBefore, err)
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
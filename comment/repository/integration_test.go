@@ -0,0 +1,471 @@
+//go:build integration
+// +build integration
+
+package repository
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/go-pg/pg"
+	"github.com/rahulbharuka/github-proxy/comment/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The tests of this file run the queries of the repository against the PostgreSQL database configured
+// by the DB_* env vars, with the schema and migrations of comment/database applied, e.g. the db of
+// docker-compose. Every test works on an org of its own, so that they leave the rest of the data alone.
+
+// newIntegrationRepo returns a repository on the test database along with a new org.
+func newIntegrationRepo(t *testing.T) (*commentRepoImpl, string) {
+	db := storage.NewDBHandler()
+	_, err := db.Exec("SELECT 1")
+	require.NoError(t, err, "PostgreSQL is down")
+	return &commentRepoImpl{db: db}, fmt.Sprintf("it-%d", time.Now().UnixNano())
+}
+
+// saveComment saves a comment of given org and fails the test on error.
+func saveComment(t *testing.T, r *commentRepoImpl, c *Comment) *Comment {
+	if c.Author == "" {
+		c.Author = "awesome-user"
+	}
+	require.NoError(t, r.Save(context.Background(), c))
+	return c
+}
+
+// commentIDs returns the IDs of given comments in order.
+func commentIDs(comments []Comment) []uint64 {
+	ids := []uint64{}
+	for _, c := range comments {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+func TestIntegrationListAllThreadRoots(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+
+	// a deleted root with an active reply, a deleted root without replies and a deleted root with a hidden reply.
+	withReply := saveComment(t, r, &Comment{Org: org, Comment: "root"})
+	reply := saveComment(t, r, &Comment{Org: org, Comment: "reply", ParentID: withReply.ID})
+	withoutReply := saveComment(t, r, &Comment{Org: org, Comment: "root"})
+	withHiddenReply := saveComment(t, r, &Comment{Org: org, Comment: "root"})
+	hiddenReply := saveComment(t, r, &Comment{Org: org, Comment: "reply", ParentID: withHiddenReply.ID})
+	active := saveComment(t, r, &Comment{Org: org, Comment: "root"})
+	for _, c := range []*Comment{withReply, withoutReply, withHiddenReply} {
+		_, err := r.Delete(ctx, org, c.ID, c.Version, "awesome-user")
+		require.NoError(t, err)
+	}
+	_, err := r.Review(ctx, org, hiddenReply.ID, true, "admin")
+	require.NoError(t, err)
+
+	comments, err := r.ListAll(ctx, org, ListOptions{RootsOnly: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{withReply.ID, active.ID}, commentIDs(comments))
+
+	comments, err = r.ListAll(ctx, org, ListOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{reply.ID, active.ID}, commentIDs(comments))
+
+	comments, err = r.ListAll(ctx, org, ListOptions{RootsOnly: true, Deleted: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{withReply.ID, withoutReply.ID, withHiddenReply.ID}, commentIDs(comments))
+}
+
+func TestIntegrationListAllFilters(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+
+	first := saveComment(t, r, &Comment{Org: org, Author: "OctoCat", Comment: "deploy"})
+	other := saveComment(t, r, &Comment{Org: org, Author: "hubot", Comment: "deploy again"})
+
+	comments, err := r.ListAll(ctx, org, ListOptions{Author: "octocat"})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{first.ID}, commentIDs(comments))
+
+	comments, err = r.ListAll(ctx, org, ListOptions{Descending: true, Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{other.ID}, commentIDs(comments))
+
+	comments, err = r.ListAll(ctx, org, ListOptions{Limit: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{first.ID}, commentIDs(comments))
+	comments, err = r.ListAll(ctx, org, ListOptions{After: &Cursor{CreatedAt: comments[0].CreatedAt, ID: comments[0].ID}})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{other.ID}, commentIDs(comments))
+}
+
+func TestIntegrationTags(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+
+	tagged := saveComment(t, r, &Comment{Org: org, Comment: "deploy", Tags: []string{"bug"}})
+	saveComment(t, r, &Comment{Org: org, Comment: "deploy again"})
+
+	comments, err := r.ListAll(ctx, org, ListOptions{Tag: "bug"})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{tagged.ID}, commentIDs(comments))
+	assert.Equal(t, []string{"bug"}, comments[0].Tags)
+
+	counts, err := r.TagCounts(ctx, org)
+	assert.NoError(t, err)
+	assert.Equal(t, []TagCount{{Tag: "bug", Count: 1}}, counts)
+}
+
+func TestIntegrationListAllRanked(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+
+	low := saveComment(t, r, &Comment{Org: org, Comment: "low"})
+	high := saveComment(t, r, &Comment{Org: org, Comment: "high"})
+	require.NoError(t, r.Vote(ctx, org, &Vote{CommentID: high.ID, Voter: "awesome-user", Value: 1}))
+	require.NoError(t, r.Vote(ctx, org, &Vote{CommentID: low.ID, Voter: "awesome-user", Value: -1}))
+
+	comments, err := r.ListAll(ctx, org, ListOptions{Rank: RankTop})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{high.ID, low.ID}, commentIDs(comments))
+
+	comments, err = r.ListAll(ctx, org, ListOptions{Rank: RankHot, RankedAt: time.Now()})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{high.ID, low.ID}, commentIDs(comments))
+}
+
+func TestIntegrationListReplies(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+
+	// the deleted reply is listed so that the reply beneath it keeps its parent, the hidden one is
+	// skipped along with its own reply.
+	root := saveComment(t, r, &Comment{Org: org, Comment: "root"})
+	deleted := saveComment(t, r, &Comment{Org: org, Comment: "reply", ParentID: root.ID})
+	nested := saveComment(t, r, &Comment{Org: org, Comment: "nested reply", ParentID: deleted.ID})
+	hidden := saveComment(t, r, &Comment{Org: org, Comment: "reply", ParentID: root.ID})
+	saveComment(t, r, &Comment{Org: org, Comment: "nested reply", ParentID: hidden.ID})
+	_, err := r.Delete(ctx, org, deleted.ID, deleted.Version, "awesome-user")
+	require.NoError(t, err)
+	_, err = r.Review(ctx, org, hidden.ID, true, "admin")
+	require.NoError(t, err)
+
+	comments, err := r.ListReplies(ctx, org, []uint64{root.ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{deleted.ID, nested.ID}, commentIDs(comments))
+	assert.True(t, comments[0].IsDeleted)
+}
+
+func TestIntegrationSearch(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+
+	match := saveComment(t, r, &Comment{Org: org, Author: "OctoCat", Comment: "deploying on friday"})
+	saveComment(t, r, &Comment{Org: org, Author: "hubot", Comment: "deploy to staging"})
+	saveComment(t, r, &Comment{Org: org, Author: "octocat", Comment: "no match"})
+
+	results, err := r.Search(ctx, org, SearchOptions{Query: "deploy -staging"})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, match.ID, results[0].ID)
+		assert.Equal(t, "<mark>deploying</mark> on friday", results[0].Snippet)
+	}
+
+	results, err = r.Search(ctx, org, SearchOptions{Query: "deploy", Author: "OCTOCAT"})
+	assert.NoError(t, err)
+	if assert.Len(t, results, 1) {
+		assert.Equal(t, match.ID, results[0].ID)
+	}
+}
+
+func TestIntegrationGetCachesHTML(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+
+	// the comment was stored before its HTML was cached.
+	var id uint64
+	_, err := r.db.QueryOne(pg.Scan(&id), "INSERT INTO comments (org, author, comment, created_at, updated_at) VALUES (?, ?, ?, now(), now()) RETURNING id",
+		org, "awesome-user", "_old_")
+	require.NoError(t, err)
+
+	c, err := r.Get(ctx, org, id)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p><em>old</em></p>", c.CommentHTML)
+
+	var stored string
+	_, err = r.db.QueryOne(pg.Scan(&stored), "SELECT comment_html FROM comments WHERE id = ?", id)
+	assert.NoError(t, err)
+	assert.Equal(t, "<p><em>old</em></p>", stored)
+}
+
+func TestIntegrationUpdate(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+
+	c := saveComment(t, r, &Comment{Org: org, Comment: "first"})
+	assert.NoError(t, r.Update(ctx, &Comment{ID: c.ID, Org: org, Comment: "second", Version: c.Version}))
+
+	c, err := r.Get(ctx, org, c.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "second", c.Comment)
+
+	revisions, err := r.ListRevisions(ctx, c.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, revisions, 1) {
+		assert.Equal(t, "first", revisions[0].Comment)
+	}
+}
+
+func TestIntegrationUpdateMentions(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+
+	c := saveComment(t, r, &Comment{Org: org, Comment: "hey @hubot", Mentions: []string{"hubot"}})
+	edit := &Comment{ID: c.ID, Org: org, Comment: "hey @octocat", Version: c.Version, Mentions: []string{"octocat"}}
+	assert.NoError(t, r.Update(ctx, edit))
+
+	c, err := r.Get(ctx, org, c.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"octocat"}, c.Mentions)
+}
+
+func TestIntegrationVersion(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+	c := saveComment(t, r, &Comment{Org: org, Comment: "comment"})
+	assert.Equal(t, 1, c.Version)
+
+	// votes and reactions leave the version alone, so that the edit based on version 1 still applies.
+	_, err := r.React(ctx, org, &Reaction{CommentID: c.ID, Reactor: "alice", Content: "+1"})
+	assert.NoError(t, err)
+	assert.NoError(t, r.Vote(ctx, org, &Vote{CommentID: c.ID, Voter: "alice", Value: 1}))
+
+	edit := &Comment{ID: c.ID, Org: org, Comment: "edited", Version: 1}
+	assert.NoError(t, r.Update(ctx, edit))
+	assert.Equal(t, 2, edit.Version)
+	assert.Equal(t, ErrVersionMismatch, r.Update(ctx, &Comment{ID: c.ID, Org: org, Comment: "stale", Version: 1}))
+}
+
+func TestIntegrationDeleteAndRestore(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+
+	c := saveComment(t, r, &Comment{Org: org, Comment: "comment"})
+	_, err := r.Delete(ctx, org, c.ID, c.Version+1, "awesome-user")
+	assert.Equal(t, ErrVersionMismatch, err)
+
+	batch, err := r.Delete(ctx, org, c.ID, c.Version, "awesome-user")
+	assert.NoError(t, err)
+	_, err = r.Get(ctx, org, c.ID)
+	assert.Equal(t, ErrNotFound, err)
+	_, err = r.Delete(ctx, org, c.ID, c.Version+1, "awesome-user")
+	assert.Equal(t, ErrNotFound, err)
+
+	restored, err := r.Restore(ctx, org, RestoreOptions{DeletionBatch: batch})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, restored)
+	c, err = r.Get(ctx, org, c.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, c.Version)
+}
+
+func TestIntegrationFlagAndReview(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+	c := saveComment(t, r, &Comment{Org: org, Comment: "spam"})
+
+	flagged, err := r.Flag(ctx, org, &Flag{CommentID: c.ID, Flagger: "alice", Reason: "spam"}, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, flagged.FlagCount)
+	assert.False(t, flagged.IsHidden, "hidden at the threshold")
+
+	_, err = r.Flag(ctx, org, &Flag{CommentID: c.ID, Flagger: "alice", Reason: "spam"}, 1)
+	assert.Equal(t, ErrAlreadyFlagged, err)
+
+	flagged, err = r.Flag(ctx, org, &Flag{CommentID: c.ID, Flagger: "bob", Reason: "spam"}, 1)
+	assert.NoError(t, err)
+	assert.True(t, flagged.IsHidden, "not hidden above the threshold")
+
+	comments, err := r.ListAll(ctx, org, ListOptions{})
+	assert.NoError(t, err)
+	assert.Empty(t, comments)
+	comments, err = r.ListAll(ctx, org, ListOptions{Flagged: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{c.ID}, commentIDs(comments))
+
+	reviewed, err := r.Review(ctx, org, c.ID, false, "admin")
+	assert.NoError(t, err)
+	assert.Equal(t, 0, reviewed.FlagCount)
+	assert.False(t, reviewed.IsHidden)
+	flags, err := r.ListFlags(ctx, []uint64{c.ID})
+	assert.NoError(t, err)
+	assert.Empty(t, flags)
+
+	// a reviewed flag does not keep its flagger from flagging again.
+	_, err = r.Flag(ctx, org, &Flag{CommentID: c.ID, Flagger: "alice", Reason: "still spam"}, 1)
+	assert.NoError(t, err)
+
+	_, err = r.Review(ctx, org, c.ID+1000000, true, "admin")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestIntegrationHidden(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+	c := saveComment(t, r, &Comment{Org: org, Comment: "spam"})
+	_, err := r.Review(ctx, org, c.ID, true, "admin")
+	require.NoError(t, err)
+
+	// hidden comments take no reactions nor votes.
+	_, err = r.React(ctx, org, &Reaction{CommentID: c.ID, Reactor: "carol", Content: "heart"})
+	assert.Equal(t, ErrNotFound, err)
+	assert.Equal(t, ErrNotFound, r.Vote(ctx, org, &Vote{CommentID: c.ID, Voter: "carol", Value: 1}))
+}
+
+func TestIntegrationPin(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+	first := saveComment(t, r, &Comment{Org: org, Comment: "first"})
+	second := saveComment(t, r, &Comment{Org: org, Comment: "second"})
+	reply := saveComment(t, r, &Comment{Org: org, Comment: "reply", ParentID: first.ID})
+
+	_, err := r.Pin(ctx, org, reply.ID, 2)
+	assert.Equal(t, ErrNotFound, err)
+
+	pinned, err := r.Pin(ctx, org, second.ID, 1)
+	assert.NoError(t, err)
+	assert.False(t, pinned.PinnedAt.IsZero())
+	_, err = r.Pin(ctx, org, first.ID, 1)
+	assert.Equal(t, ErrPinLimit, err)
+
+	comments, err := r.ListAll(ctx, org, ListOptions{RootsOnly: true, PinnedFirst: true})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{second.ID, first.ID}, commentIDs(comments))
+
+	_, err = r.Unpin(ctx, org, second.ID)
+	assert.NoError(t, err)
+	_, err = r.Unpin(ctx, org, second.ID)
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestIntegrationReact(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+	c := saveComment(t, r, &Comment{Org: org, Comment: "comment"})
+
+	created, err := r.React(ctx, org, &Reaction{CommentID: c.ID, Reactor: "alice", Content: "+1"})
+	assert.NoError(t, err)
+	assert.True(t, created)
+	created, err = r.React(ctx, org, &Reaction{CommentID: c.ID, Reactor: "alice", Content: "+1"})
+	assert.NoError(t, err)
+	assert.False(t, created)
+	_, err = r.React(ctx, org, &Reaction{CommentID: c.ID, Reactor: "bob", Content: "+1"})
+	assert.NoError(t, err)
+
+	got, err := r.Get(ctx, org, c.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"+1": 2}, got.Reactions)
+
+	assert.NoError(t, r.Unreact(ctx, org, &Reaction{CommentID: c.ID, Reactor: "alice", Content: "+1"}))
+	assert.Equal(t, ErrNotFound, r.Unreact(ctx, org, &Reaction{CommentID: c.ID, Reactor: "alice", Content: "+1"}))
+	got, err = r.Get(ctx, org, c.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"+1": 1}, got.Reactions)
+}
+
+func TestIntegrationVote(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+	c := saveComment(t, r, &Comment{Org: org, Comment: "comment"})
+
+	assert.NoError(t, r.Vote(ctx, org, &Vote{CommentID: c.ID, Voter: "alice", Value: 1}))
+	assert.NoError(t, r.Vote(ctx, org, &Vote{CommentID: c.ID, Voter: "bob", Value: 1}))
+	assert.NoError(t, r.Vote(ctx, org, &Vote{CommentID: c.ID, Voter: "bob", Value: -1}))
+	got, err := r.Get(ctx, org, c.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, got.Score)
+
+	// a zero vote takes the vote back.
+	assert.NoError(t, r.Vote(ctx, org, &Vote{CommentID: c.ID, Voter: "bob", Value: 0}))
+	got, err = r.Get(ctx, org, c.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, got.Score)
+}
+
+func TestIntegrationListMentions(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+
+	// the login is unique to the test, as mentions are listed across orgs.
+	login := "octocat-" + org
+	older := saveComment(t, r, &Comment{Org: org, Comment: "hey", Mentions: []string{login}})
+	newer := saveComment(t, r, &Comment{Org: org, Comment: "hey again", Mentions: []string{login}})
+	saveComment(t, r, &Comment{Org: org, Comment: "not for you"})
+
+	comments, err := r.ListMentions(ctx, login, 1, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{newer.ID}, commentIDs(comments))
+
+	comments, err = r.ListMentions(ctx, login, 10, &Cursor{CreatedAt: comments[0].CreatedAt, ID: comments[0].ID})
+	assert.NoError(t, err)
+	assert.Equal(t, []uint64{older.ID}, commentIDs(comments))
+}
+
+func TestIntegrationIdempotencyKey(t *testing.T) {
+	r, org := newIntegrationRepo(t)
+	ctx := context.Background()
+	now := time.Now()
+	claim := func(createdAt time.Time) *IdempotentResponse {
+		return &IdempotentResponse{
+			IdempotencyKey: "key",
+			Org:            org,
+			Author:         "awesome-user",
+			RequestHash:    fmt.Sprintf("%064d", 1),
+			CreatedAt:      createdAt,
+			ExpiresAt:      createdAt.Add(time.Hour),
+		}
+	}
+
+	existing, err := r.ClaimIdempotencyKey(ctx, claim(now))
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+
+	existing, err = r.ClaimIdempotencyKey(ctx, claim(now))
+	assert.NoError(t, err)
+	if assert.NotNil(t, existing) {
+		assert.Equal(t, 0, existing.Status)
+	}
+
+	resp := claim(now)
+	resp.Status = http.StatusCreated
+	resp.Body = []byte(`{"id":1}`)
+	resp.Headers = http.Header{"Location": {"/orgs/" + org + "/comments/1"}}
+	assert.NoError(t, r.CompleteIdempotencyKey(ctx, resp))
+
+	existing, err = r.ClaimIdempotencyKey(ctx, claim(now))
+	assert.NoError(t, err)
+	if assert.NotNil(t, existing) {
+		assert.Equal(t, http.StatusCreated, existing.Status)
+		assert.Equal(t, resp.Body, existing.Body)
+		assert.Equal(t, resp.Headers, existing.Headers)
+	}
+
+	// an expired response is replaced.
+	existing, err = r.ClaimIdempotencyKey(ctx, claim(now.Add(2*time.Hour)))
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+	existing, err = r.ClaimIdempotencyKey(ctx, claim(now.Add(2*time.Hour)))
+	assert.NoError(t, err)
+	if assert.NotNil(t, existing) {
+		assert.Equal(t, 0, existing.Status)
+		assert.Empty(t, existing.Body)
+		assert.Empty(t, existing.Headers)
+	}
+
+	assert.NoError(t, r.ReleaseIdempotencyKey(ctx, claim(now)))
+	existing, err = r.ClaimIdempotencyKey(ctx, claim(now))
+	assert.NoError(t, err)
+	assert.Nil(t, existing)
+	assert.NoError(t, r.ReleaseIdempotencyKey(ctx, claim(now)))
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	context "context"
+	"fmt"
+	"log"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// Mention is a storage object for comment_mentions table.
+// It holds an org member mentioned as @login in a comment, in lower case.
+type Mention struct {
+	tableName struct{} `sql:"comment_mentions"`
+
+	CommentID uint64 `sql:",pk"`
+	Org       string
+	Login     string `sql:",pk"`
+}
+
+// String ...
+func (m Mention) String() string {
+	return fmt.Sprintf("Mention<%d %s %s>", m.CommentID, m.Org, m.Login)
+}
+
+// ListMentions lists the active, visible comments of all orgs mentioning given login, newest first.
+// It returns up to limit comments after the cursor, if any.
+func (r *commentRepoImpl) ListMentions(ctx context.Context, login string, limit int, after *Cursor) ([]Comment, error) {
+	var comments []Comment
+	q := r.db.Model(&comments).
+		Where("id IN (SELECT comment_id FROM comment_mentions WHERE login=?)", login).
+		Where("is_deleted=? and is_hidden=?", false, false)
+	if after != nil {
+		q = q.Where("(created_at, id) < (?, ?)", after.CreatedAt, after.ID)
+	}
+
+	err := q.Order("created_at DESC", "id DESC").Limit(limit).Select()
+	if err == nil {
+		err = r.loadRelations(comments)
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to list mentions of %v, err: %v", login, err)
+		return nil, err
+	}
+
+	return comments, nil
+}
+
+// saveMentions inserts the mentions of a saved comment.
+func saveMentions(db orm.DB, c *Comment) error {
+	if len(c.Mentions) == 0 {
+		return nil
+	}
+
+	mentions := make([]Mention, len(c.Mentions))
+	for i, login := range c.Mentions {
+		mentions[i] = Mention{CommentID: c.ID, Org: c.Org, Login: login}
+	}
+	_, err := db.Model(&mentions).Insert()
+	return err
+}
+
+// replaceMentions replaces the mentions of an edited comment.
+func replaceMentions(db orm.DB, c *Comment) error {
+	_, err := db.Model((*Mention)(nil)).Where("comment_id=?", c.ID).Delete()
+	if err != nil {
+		return err
+	}
+	return saveMentions(db, c)
+}
+
+// loadMentions sets the mentions of given comments in alphabetical order.
+func (r *commentRepoImpl) loadMentions(comments []Comment) error {
+	ids, index := indexComments(comments)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var mentions []Mention
+	err := r.db.Model(&mentions).Where("comment_id IN (?)", pg.In(ids)).Order("comment_id ASC", "login ASC").Select()
+	if err != nil {
+		return err
+	}
+	for _, m := range mentions {
+		c := &comments[index[m.CommentID]]
+		c.Mentions = append(c.Mentions, m.Login)
+	}
+	return nil
+}
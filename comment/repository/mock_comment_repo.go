@@ -4,6 +4,7 @@ package repository
 
 import (
 	context "context"
+	time "time"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -13,20 +14,127 @@ type MockCommentRepo struct {
 	mock.Mock
 }
 
-// ListAll provides a mock function with given fields: ctx, org
-func (_m *MockCommentRepo) ListAll(ctx context.Context, org string) ([]Comment, error) {
-	ret := _m.Called(ctx, org)
+// ListAll provides a mock function with given fields: ctx, org, opts
+func (_m *MockCommentRepo) ListAll(ctx context.Context, org string, opts ListOptions) ([]Comment, error) {
+	ret := _m.Called(ctx, org, opts)
 
 	var r0 []Comment
-	if rf, ok := ret.Get(0).(func(context.Context, string) []Comment); ok {
-		r0 = rf(ctx, org)
+	if rf, ok := ret.Get(0).(func(context.Context, string, ListOptions) []Comment); ok {
+		r0 = rf(ctx, org, opts)
 	} else {
 		r0 = ret.Get(0).([]Comment)
 	}
 
 	var r1 error
-	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
-		r1 = rf(ctx, org)
+	if rf, ok := ret.Get(1).(func(context.Context, string, ListOptions) error); ok {
+		r1 = rf(ctx, org, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListReplies provides a mock function with given fields: ctx, org, rootIDs
+func (_m *MockCommentRepo) ListReplies(ctx context.Context, org string, rootIDs []uint64) ([]Comment, error) {
+	ret := _m.Called(ctx, org, rootIDs)
+
+	var r0 []Comment
+	if rf, ok := ret.Get(0).(func(context.Context, string, []uint64) []Comment); ok {
+		r0 = rf(ctx, org, rootIDs)
+	} else {
+		r0 = ret.Get(0).([]Comment)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []uint64) error); ok {
+		r1 = rf(ctx, org, rootIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Search provides a mock function with given fields: ctx, org, opts
+func (_m *MockCommentRepo) Search(ctx context.Context, org string, opts SearchOptions) ([]SearchResult, error) {
+	ret := _m.Called(ctx, org, opts)
+
+	var r0 []SearchResult
+	if rf, ok := ret.Get(0).(func(context.Context, string, SearchOptions) []SearchResult); ok {
+		r0 = rf(ctx, org, opts)
+	} else {
+		r0 = ret.Get(0).([]SearchResult)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, SearchOptions) error); ok {
+		r1 = rf(ctx, org, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListRevisions provides a mock function with given fields: ctx, commentID
+func (_m *MockCommentRepo) ListRevisions(ctx context.Context, commentID uint64) ([]Revision, error) {
+	ret := _m.Called(ctx, commentID)
+
+	var r0 []Revision
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) []Revision); ok {
+		r0 = rf(ctx, commentID)
+	} else {
+		r0 = ret.Get(0).([]Revision)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, uint64) error); ok {
+		r1 = rf(ctx, commentID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Get provides a mock function with given fields: ctx, org, id
+func (_m *MockCommentRepo) Get(ctx context.Context, org string, id uint64) (*Comment, error) {
+	ret := _m.Called(ctx, org, id)
+
+	var r0 *Comment
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint64) *Comment); ok {
+		r0 = rf(ctx, org, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Comment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, uint64) error); ok {
+		r1 = rf(ctx, org, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// HasDuplicate provides a mock function with given fields: ctx, org, author, comment, since
+func (_m *MockCommentRepo) HasDuplicate(ctx context.Context, org string, author string, comment string, since time.Time) (bool, error) {
+	ret := _m.Called(ctx, org, author, comment, since)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, time.Time) bool); ok {
+		r0 = rf(ctx, org, author, comment, since)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, time.Time) error); ok {
+		r1 = rf(ctx, org, author, comment, since)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -48,16 +156,404 @@ func (_m *MockCommentRepo) Save(ctx context.Context, c *Comment) error {
 	return r0
 }
 
-// DeleteAll provides a mock function with given fields: ctx, org
-func (_m *MockCommentRepo) DeleteAll(ctx context.Context, org string) error {
-	ret := _m.Called(ctx, org)
+// Update provides a mock function with given fields: ctx, c
+func (_m *MockCommentRepo) Update(ctx context.Context, c *Comment) error {
+	ret := _m.Called(ctx, c)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, *Comment) error); ok {
+		r0 = rf(ctx, c)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Delete provides a mock function with given fields: ctx, org, id, version, deletedBy
+func (_m *MockCommentRepo) Delete(ctx context.Context, org string, id uint64, version int, deletedBy string) (string, error) {
+	ret := _m.Called(ctx, org, id, version, deletedBy)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint64, int, string) string); ok {
+		r0 = rf(ctx, org, id, version, deletedBy)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, uint64, int, string) error); ok {
+		r1 = rf(ctx, org, id, version, deletedBy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// DeleteAll provides a mock function with given fields: ctx, org, deletedBy
+func (_m *MockCommentRepo) DeleteAll(ctx context.Context, org string, deletedBy string) (string, error) {
+	ret := _m.Called(ctx, org, deletedBy)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) string); ok {
+		r0 = rf(ctx, org, deletedBy)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, org, deletedBy)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Restore provides a mock function with given fields: ctx, org, opts
+func (_m *MockCommentRepo) Restore(ctx context.Context, org string, opts RestoreOptions) (int, error) {
+	ret := _m.Called(ctx, org, opts)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, string, RestoreOptions) int); ok {
+		r0 = rf(ctx, org, opts)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, RestoreOptions) error); ok {
+		r1 = rf(ctx, org, opts)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Purge provides a mock function with given fields: ctx, deletedBefore, limit
+func (_m *MockCommentRepo) Purge(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	ret := _m.Called(ctx, deletedBefore, limit)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time, int) int); ok {
+		r0 = rf(ctx, deletedBefore, limit)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time, int) error); ok {
+		r1 = rf(ctx, deletedBefore, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Export provides a mock function with given fields: ctx, org, opts, fn
+func (_m *MockCommentRepo) Export(ctx context.Context, org string, opts ExportOptions, fn func(*Comment) error) error {
+	ret := _m.Called(ctx, org, opts, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ExportOptions, func(*Comment) error) error); ok {
+		r0 = rf(ctx, org, opts, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Import provides a mock function with given fields: ctx, comments
+func (_m *MockCommentRepo) Import(ctx context.Context, comments []Comment) error {
+	ret := _m.Called(ctx, comments)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, []Comment) error); ok {
+		r0 = rf(ctx, comments)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TagCounts provides a mock function with given fields: ctx, org
+func (_m *MockCommentRepo) TagCounts(ctx context.Context, org string) ([]TagCount, error) {
+	ret := _m.Called(ctx, org)
+
+	var r0 []TagCount
+	if rf, ok := ret.Get(0).(func(context.Context, string) []TagCount); ok {
 		r0 = rf(ctx, org)
+	} else {
+		r0 = ret.Get(0).([]TagCount)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, org)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Flag provides a mock function with given fields: ctx, org, f, threshold
+func (_m *MockCommentRepo) Flag(ctx context.Context, org string, f *Flag, threshold int) (*Comment, error) {
+	ret := _m.Called(ctx, org, f, threshold)
+
+	var r0 *Comment
+	if rf, ok := ret.Get(0).(func(context.Context, string, *Flag, int) *Comment); ok {
+		r0 = rf(ctx, org, f, threshold)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Comment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *Flag, int) error); ok {
+		r1 = rf(ctx, org, f, threshold)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListFlags provides a mock function with given fields: ctx, commentIDs
+func (_m *MockCommentRepo) ListFlags(ctx context.Context, commentIDs []uint64) ([]Flag, error) {
+	ret := _m.Called(ctx, commentIDs)
+
+	var r0 []Flag
+	if rf, ok := ret.Get(0).(func(context.Context, []uint64) []Flag); ok {
+		r0 = rf(ctx, commentIDs)
+	} else {
+		r0 = ret.Get(0).([]Flag)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, []uint64) error); ok {
+		r1 = rf(ctx, commentIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Review provides a mock function with given fields: ctx, org, id, hide, reviewer
+func (_m *MockCommentRepo) Review(ctx context.Context, org string, id uint64, hide bool, reviewer string) (*Comment, error) {
+	ret := _m.Called(ctx, org, id, hide, reviewer)
+
+	var r0 *Comment
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint64, bool, string) *Comment); ok {
+		r0 = rf(ctx, org, id, hide, reviewer)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Comment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, uint64, bool, string) error); ok {
+		r1 = rf(ctx, org, id, hide, reviewer)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Pin provides a mock function with given fields: ctx, org, id, maxPinned
+func (_m *MockCommentRepo) Pin(ctx context.Context, org string, id uint64, maxPinned int) (*Comment, error) {
+	ret := _m.Called(ctx, org, id, maxPinned)
+
+	var r0 *Comment
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint64, int) *Comment); ok {
+		r0 = rf(ctx, org, id, maxPinned)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Comment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, uint64, int) error); ok {
+		r1 = rf(ctx, org, id, maxPinned)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Unpin provides a mock function with given fields: ctx, org, id
+func (_m *MockCommentRepo) Unpin(ctx context.Context, org string, id uint64) (*Comment, error) {
+	ret := _m.Called(ctx, org, id)
+
+	var r0 *Comment
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint64) *Comment); ok {
+		r0 = rf(ctx, org, id)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Comment)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, uint64) error); ok {
+		r1 = rf(ctx, org, id)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// React provides a mock function with given fields: ctx, org, reaction
+func (_m *MockCommentRepo) React(ctx context.Context, org string, reaction *Reaction) (bool, error) {
+	ret := _m.Called(ctx, org, reaction)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, *Reaction) bool); ok {
+		r0 = rf(ctx, org, reaction)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, *Reaction) error); ok {
+		r1 = rf(ctx, org, reaction)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Unreact provides a mock function with given fields: ctx, org, reaction
+func (_m *MockCommentRepo) Unreact(ctx context.Context, org string, reaction *Reaction) error {
+	ret := _m.Called(ctx, org, reaction)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *Reaction) error); ok {
+		r0 = rf(ctx, org, reaction)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Vote provides a mock function with given fields: ctx, org, v
+func (_m *MockCommentRepo) Vote(ctx context.Context, org string, v *Vote) error {
+	ret := _m.Called(ctx, org, v)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, *Vote) error); ok {
+		r0 = rf(ctx, org, v)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ListMentions provides a mock function with given fields: ctx, login, limit, after
+func (_m *MockCommentRepo) ListMentions(ctx context.Context, login string, limit int, after *Cursor) ([]Comment, error) {
+	ret := _m.Called(ctx, login, limit, after)
+
+	var r0 []Comment
+	if rf, ok := ret.Get(0).(func(context.Context, string, int, *Cursor) []Comment); ok {
+		r0 = rf(ctx, login, limit, after)
+	} else {
+		r0 = ret.Get(0).([]Comment)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, int, *Cursor) error); ok {
+		r1 = rf(ctx, login, limit, after)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClaimIdempotencyKey provides a mock function with given fields: ctx, resp
+func (_m *MockCommentRepo) ClaimIdempotencyKey(ctx context.Context, resp *IdempotentResponse) (*IdempotentResponse, error) {
+	ret := _m.Called(ctx, resp)
+
+	var r0 *IdempotentResponse
+	if rf, ok := ret.Get(0).(func(context.Context, *IdempotentResponse) *IdempotentResponse); ok {
+		r0 = rf(ctx, resp)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*IdempotentResponse)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, *IdempotentResponse) error); ok {
+		r1 = rf(ctx, resp)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// CompleteIdempotencyKey provides a mock function with given fields: ctx, resp
+func (_m *MockCommentRepo) CompleteIdempotencyKey(ctx context.Context, resp *IdempotentResponse) error {
+	ret := _m.Called(ctx, resp)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *IdempotentResponse) error); ok {
+		r0 = rf(ctx, resp)
 	} else {
 		r0 = ret.Error(0)
 	}
 
 	return r0
 }
+
+// ReleaseIdempotencyKey provides a mock function with given fields: ctx, resp
+func (_m *MockCommentRepo) ReleaseIdempotencyKey(ctx context.Context, resp *IdempotentResponse) error {
+	ret := _m.Called(ctx, resp)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *IdempotentResponse) error); ok {
+		r0 = rf(ctx, resp)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// PurgeIdempotencyKeys provides a mock function with given fields: ctx, expiredBefore
+func (_m *MockCommentRepo) PurgeIdempotencyKeys(ctx context.Context, expiredBefore time.Time) (int, error) {
+	ret := _m.Called(ctx, expiredBefore)
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func(context.Context, time.Time) int); ok {
+		r0 = rf(ctx, expiredBefore)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = rf(ctx, expiredBefore)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
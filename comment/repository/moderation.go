@@ -0,0 +1,120 @@
+package repository
+
+import (
+	context "context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-pg/pg"
+)
+
+// ErrAlreadyFlagged ...
+var ErrAlreadyFlagged = errors.New("comment already flagged by user")
+
+// Flag is a storage object for comment_flags table.
+// It holds a report of a comment by a user, pending until a moderator reviews the comment.
+type Flag struct {
+	tableName struct{} `sql:"comment_flags"`
+
+	ID         uint64    `json:"id"`
+	CommentID  uint64    `json:"comment_id"`
+	Flagger    string    `json:"flagger"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+	ReviewedAt time.Time `json:"reviewed_at"`
+}
+
+// String ...
+func (f Flag) String() string {
+	return fmt.Sprintf("Flag<%d %d %s %s %v %v>", f.ID, f.CommentID, f.Flagger, f.Reason, f.CreatedAt, f.ReviewedAt)
+}
+
+// Flag stores a pending flag of an active comment of given org and returns the flagged comment.
+// The comment is hidden once it has more than threshold pending flags, unless threshold is zero.
+func (r *commentRepoImpl) Flag(ctx context.Context, org string, f *Flag, threshold int) (*Comment, error) {
+	c := &Comment{}
+	err := r.db.RunInTransaction(func(tx *pg.Tx) error {
+		err := tx.Model(c).Where("id=? and org=? and is_deleted=?", f.CommentID, org, false).For("UPDATE").Select()
+		if err == pg.ErrNoRows {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		f.CreatedAt = time.Now()
+		resp, err := tx.Model(f).OnConflict("(comment_id, flagger) WHERE reviewed_at IS NULL DO NOTHING").Insert()
+		if err != nil {
+			return err
+		}
+		if resp.RowsAffected() <= 0 {
+			return ErrAlreadyFlagged
+		}
+
+		c.FlagCount++
+		if threshold > 0 && c.FlagCount > threshold {
+			c.IsHidden = true
+		}
+		_, err = tx.Model(c).Set("flag_count=?, is_hidden=?", c.FlagCount, c.IsHidden).WherePK().Update()
+		return err
+	})
+
+	if err == ErrNotFound || err == ErrAlreadyFlagged {
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to flag comment %v for org %v, err: %v", f.CommentID, org, err)
+		return nil, err
+	}
+	return c, nil
+}
+
+// ListFlags lists the pending flags of given comments ordered by id.
+func (r *commentRepoImpl) ListFlags(ctx context.Context, commentIDs []uint64) ([]Flag, error) {
+	var flags []Flag
+	if len(commentIDs) == 0 {
+		return flags, nil
+	}
+
+	err := r.db.Model(&flags).Where("comment_id IN (?) and reviewed_at IS NULL", pg.In(commentIDs)).Order("id ASC").Select()
+	if err != nil {
+		log.Printf("ERROR: failed to list flags for comments %v, err: %v", commentIDs, err)
+		return nil, err
+	}
+
+	return flags, nil
+}
+
+// Review resolves the pending flags of an active comment of given org by either hiding
+// or approving (showing) the comment, and returns the reviewed comment.
+func (r *commentRepoImpl) Review(ctx context.Context, org string, id uint64, hide bool, reviewer string) (*Comment, error) {
+	c := &Comment{}
+	reviewedAt := time.Now()
+	err := r.db.RunInTransaction(func(tx *pg.Tx) error {
+		_, err := tx.Model(c).
+			Set("flag_count=?, is_hidden=?, reviewed_by=?, reviewed_at=?", 0, hide, reviewer, reviewedAt).
+			Where("id=? and org=? and is_deleted=?", id, org, false).
+			Returning("*").
+			Update()
+		if err == pg.ErrNoRows {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Model((*Flag)(nil)).Set("reviewed_at=?", reviewedAt).Where("comment_id=? and reviewed_at IS NULL", id).Update()
+		return err
+	})
+
+	if err == ErrNotFound {
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to review comment %v for org %v, err: %v", id, org, err)
+		return nil, err
+	}
+	return c, nil
+}
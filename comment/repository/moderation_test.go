@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReviewNotFound(t *testing.T) {
+	// no active comment of the org matches, so the update returns no row.
+	r, fake := newFakeRepo(t, func(query string) fakeResult {
+		return fakeResult{tag: "UPDATE 0"}
+	})
+	defer fake.Close()
+
+	c, err := r.Review(context.Background(), "github", 1, true, "admin")
+	assert.Equal(t, ErrNotFound, err)
+	assert.Nil(t, c)
+}
+
+func TestFlagHidesAboveThreshold(t *testing.T) {
+	// the comment has flagCount pending flags before the new one.
+	flag := func(flagCount string) *Comment {
+		r, fake := newFakeRepo(t, func(query string) fakeResult {
+			switch {
+			case strings.HasPrefix(query, "SELECT"):
+				return fakeResult{columns: []string{"id", "org", "flag_count"}, rows: [][]string{{"1", "github", flagCount}}, tag: "SELECT 1"}
+			case strings.HasPrefix(query, "INSERT"):
+				return fakeResult{tag: "INSERT 0 1"}
+			}
+			return fakeResult{tag: "UPDATE 1"}
+		})
+		defer fake.Close()
+
+		c, err := r.Flag(context.Background(), "github", &Flag{CommentID: 1, Flagger: "awesome-user", Reason: "spam"}, 3)
+		assert.NoError(t, err)
+		return c
+	}
+
+	c := flag("2")
+	assert.Equal(t, 3, c.FlagCount)
+	assert.False(t, c.IsHidden, "hidden at the threshold")
+
+	c = flag("3")
+	assert.Equal(t, 4, c.FlagCount)
+	assert.True(t, c.IsHidden, "not hidden above the threshold")
+}
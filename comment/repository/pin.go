@@ -0,0 +1,69 @@
+package repository
+
+import (
+	context "context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/go-pg/pg"
+)
+
+// ErrPinLimit is returned when an org already has its maximum number of pinned comments.
+var ErrPinLimit = errors.New("org already has its maximum number of pinned comments")
+
+// Pin pins an active, unpinned top level comment of given org and returns the pinned comment.
+// It fails with ErrPinLimit if the org already has maxPinned pinned comments.
+func (r *commentRepoImpl) Pin(ctx context.Context, org string, id uint64, maxPinned int) (*Comment, error) {
+	c := &Comment{}
+	err := r.db.RunInTransaction(func(tx *pg.Tx) error {
+		// serialize the pins of an org, so that concurrent ones can not exceed the limit.
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", "pin:"+org); err != nil {
+			return err
+		}
+		pinned, err := tx.Model((*Comment)(nil)).Where("org=? and is_deleted=? and pinned_at IS NOT NULL", org, false).Count()
+		if err != nil {
+			return err
+		}
+		if pinned >= maxPinned {
+			return ErrPinLimit
+		}
+
+		_, err = tx.Model(c).
+			Set("pinned_at=?", time.Now()).
+			Where("id=? and org=? and is_deleted=? and parent_id IS NULL and pinned_at IS NULL", id, org, false).
+			Returning("*").
+			Update()
+		if err == pg.ErrNoRows {
+			return ErrNotFound
+		}
+		return err
+	})
+
+	if err == ErrNotFound || err == ErrPinLimit {
+		return nil, err
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to pin comment %v for org %v, err: %v", id, org, err)
+		return nil, err
+	}
+	return c, nil
+}
+
+// Unpin unpins an active, pinned comment of given org and returns the unpinned comment.
+func (r *commentRepoImpl) Unpin(ctx context.Context, org string, id uint64) (*Comment, error) {
+	c := &Comment{}
+	_, err := r.db.Model(c).
+		Set("pinned_at=NULL").
+		Where("id=? and org=? and is_deleted=? and pinned_at IS NOT NULL", id, org, false).
+		Returning("*").
+		Update()
+	if err == pg.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to unpin comment %v for org %v, err: %v", id, org, err)
+		return nil, err
+	}
+	return c, nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPinNotFound(t *testing.T) {
+	// the org has no pinned comment, and the comment to pin is gone, e.g. deleted concurrently.
+	r, fake := newFakeRepo(t, func(query string) fakeResult {
+		switch {
+		case strings.Contains(query, "count(*)"):
+			return fakeResult{columns: []string{"count"}, rows: [][]string{{"0"}}, tag: "SELECT 1"}
+		case strings.HasPrefix(query, "SELECT"):
+			return fakeResult{tag: "SELECT 0"}
+		}
+		return fakeResult{tag: "UPDATE 0"}
+	})
+	defer fake.Close()
+
+	c, err := r.Pin(context.Background(), "github", 1, 3)
+	assert.Equal(t, ErrNotFound, err)
+	assert.Nil(t, c)
+}
+
+func TestUnpinNotFound(t *testing.T) {
+	r, fake := newFakeRepo(t, func(query string) fakeResult {
+		return fakeResult{tag: "UPDATE 0"}
+	})
+	defer fake.Close()
+
+	c, err := r.Unpin(context.Background(), "github", 1)
+	assert.Equal(t, ErrNotFound, err)
+	assert.Nil(t, c)
+}
@@ -0,0 +1,104 @@
+package repository
+
+import (
+	context "context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-pg/pg"
+)
+
+// Reaction is a storage object for comment_reactions table.
+// A user can react to a comment once with every kind of reaction, e.g. +1 and heart.
+type Reaction struct {
+	tableName struct{} `sql:"comment_reactions"`
+
+	ID        uint64    `json:"id"`
+	CommentID uint64    `json:"comment_id"`
+	Reactor   string    `json:"reactor"`
+	Content   string    `json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// String ...
+func (r Reaction) String() string {
+	return fmt.Sprintf("Reaction<%d %d %s %s %v>", r.ID, r.CommentID, r.Reactor, r.Content, r.CreatedAt)
+}
+
+// React stores a reaction to an active, visible comment of given org. It tells whether the reaction is new,
+// reacting twice the same way is not an error.
+func (r *commentRepoImpl) React(ctx context.Context, org string, reaction *Reaction) (bool, error) {
+	var created bool
+	err := r.db.RunInTransaction(func(tx *pg.Tx) error {
+		c := &Comment{}
+		err := tx.Model(c).Column("id").Where("id=? and org=? and is_deleted=? and is_hidden=?", reaction.CommentID, org, false, false).For("UPDATE").Select()
+		if err == pg.ErrNoRows {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		reaction.CreatedAt = time.Now()
+		resp, err := tx.Model(reaction).OnConflict("(comment_id, reactor, content) DO NOTHING").Insert()
+		if err != nil {
+			return err
+		}
+		created = resp.RowsAffected() > 0
+		return nil
+	})
+
+	if err == ErrNotFound {
+		return false, err
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to store reaction %+v for org %v, err: %v", reaction, org, err)
+		return false, err
+	}
+	return created, nil
+}
+
+// Unreact removes a reaction from an active, visible comment of given org.
+func (r *commentRepoImpl) Unreact(ctx context.Context, org string, reaction *Reaction) error {
+	resp, err := r.db.Model((*Reaction)(nil)).
+		Where("comment_id=? and reactor=? and content=?", reaction.CommentID, reaction.Reactor, reaction.Content).
+		Where("comment_id IN (SELECT id FROM comments WHERE org=? and is_deleted=? and is_hidden=?)", org, false, false).
+		Delete()
+	if err != nil {
+		log.Printf("ERROR: failed to remove reaction %+v for org %v, err: %v", reaction, org, err)
+		return err
+	}
+	if resp.RowsAffected() <= 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// loadReactions sets the reaction counts of given comments.
+func (r *commentRepoImpl) loadReactions(comments []Comment) error {
+	ids, index := indexComments(comments)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var counts []struct {
+		CommentID uint64
+		Content   string
+		Count     int
+	}
+	_, err := r.db.Query(&counts, `
+		SELECT comment_id, content, count(*) AS count FROM comment_reactions
+		WHERE comment_id IN (?) GROUP BY comment_id, content`, pg.In(ids))
+	if err != nil {
+		return err
+	}
+	for _, rc := range counts {
+		c := &comments[index[rc.CommentID]]
+		if c.Reactions == nil {
+			c.Reactions = make(map[string]int)
+		}
+		c.Reactions[rc.Content] = rc.Count
+	}
+	return nil
+}
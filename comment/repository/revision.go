@@ -0,0 +1,38 @@
+package repository
+
+import (
+	context "context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Revision is a storage object for comment_revisions table.
+// It holds a prior text of a comment that was replaced by an edit.
+type Revision struct {
+	tableName struct{} `sql:"comment_revisions"`
+
+	ID        uint64 `json:"id"`
+	CommentID uint64 `json:"comment_id"`
+	Comment   string `json:"comment"`
+	// CreatedAt is when this text was written and ReplacedAt is when it was edited away.
+	CreatedAt  time.Time `json:"created_at"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// String ...
+func (r Revision) String() string {
+	return fmt.Sprintf("Revision<%d %d %s %v %v>", r.ID, r.CommentID, r.Comment, r.CreatedAt, r.ReplacedAt)
+}
+
+// ListRevisions lists all prior texts of given comment, oldest first.
+func (r *commentRepoImpl) ListRevisions(ctx context.Context, commentID uint64) ([]Revision, error) {
+	var revisions []Revision
+	err := r.db.Model(&revisions).Where("comment_id=?", commentID).Order("id ASC").Select()
+	if err != nil {
+		log.Printf("ERROR: failed to list revisions for comment %v, err: %v", commentID, err)
+		return nil, err
+	}
+
+	return revisions, nil
+}
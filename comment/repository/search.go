@@ -0,0 +1,102 @@
+package repository
+
+import (
+	context "context"
+	"html"
+	"log"
+	"strings"
+	"time"
+)
+
+// snippetStart and snippetStop mark the matches in snippets as returned by the DB. They are private use
+// characters, taken out of the comments beforehand, so that the snippet can be escaped before they are
+// turned into <mark> tags.
+const (
+	snippetStart = "\uE000"
+	snippetStop  = "\uE001"
+)
+
+var snippetMarks = strings.NewReplacer(snippetStart, "<mark>", snippetStop, "</mark>")
+
+// SearchOptions narrows the comments returned by Search.
+type SearchOptions struct {
+	// Query is a web search style query, e.g. `deploy -staging "on call"`.
+	Query string
+	// Author narrows the results to comments of the author, regardless of case.
+	Author string
+	// Since and Until bound the creation time of matching comments when not zero.
+	Since time.Time
+	Until time.Time
+	Limit int
+}
+
+// SearchResult is a comment matching a search along with its rank and highlighted snippet.
+// The snippet is HTML: the comment text is escaped and the matches are wrapped in <mark> tags.
+type SearchResult struct {
+	tableName struct{} `pg:",discard_unknown_columns"`
+
+	Comment
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// Search runs a full-text search over active, visible comments of given org, best matches first.
+// The results carry the tags, reactions and mentions of the comments.
+func (r *commentRepoImpl) Search(ctx context.Context, org string, opts SearchOptions) ([]SearchResult, error) {
+	var results []SearchResult
+	q := r.db.Model().
+		TableExpr("comments AS c").
+		TableExpr("websearch_to_tsquery('english', ?) AS query", opts.Query).
+		ColumnExpr("c.*").
+		ColumnExpr("ts_rank(c.search_vector, query) AS rank").
+		ColumnExpr("ts_headline('english', translate(c.comment, ?, ''), query, ?) AS snippet",
+			snippetStart+snippetStop, `StartSel="`+snippetStart+`", StopSel="`+snippetStop+`"`).
+		Where("c.org = ? AND c.is_deleted = ? AND c.is_hidden = ?", org, false, false).
+		Where("c.search_vector @@ query")
+	if opts.Author != "" {
+		q = q.Where("lower(c.author) = lower(?)", opts.Author)
+	}
+	if !opts.Since.IsZero() {
+		q = q.Where("c.created_at >= ?", opts.Since)
+	}
+	if !opts.Until.IsZero() {
+		q = q.Where("c.created_at < ?", opts.Until)
+	}
+	if opts.Limit > 0 {
+		q = q.Limit(opts.Limit)
+	}
+
+	err := q.OrderExpr("rank DESC, c.created_at DESC, c.id DESC").Select(&results)
+	if err == nil {
+		err = r.loadSearchRelations(results)
+	}
+	if err != nil {
+		log.Printf("ERROR: failed to search comments for org %v, err: %v", org, err)
+		return nil, err
+	}
+
+	for i := range results {
+		results[i].Snippet = highlightSnippet(results[i].Snippet)
+	}
+	return results, nil
+}
+
+// highlightSnippet escapes a snippet as returned by the DB and wraps its matches in <mark> tags.
+func highlightSnippet(snippet string) string {
+	return snippetMarks.Replace(html.EscapeString(snippet))
+}
+
+// loadSearchRelations sets the tags, reaction counts and mentions of the comments of given results.
+func (r *commentRepoImpl) loadSearchRelations(results []SearchResult) error {
+	comments := make([]Comment, len(results))
+	for i := range results {
+		comments[i] = results[i].Comment
+	}
+	if err := r.loadRelations(comments); err != nil {
+		return err
+	}
+	for i := range results {
+		results[i].Comment = comments[i]
+	}
+	return nil
+}
@@ -0,0 +1,13 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHighlightSnippet(t *testing.T) {
+	snippet := `<img src=x onerror="alert(1)"> ` + snippetStart + "deploy" + snippetStop + " & roll back"
+	assert.Equal(t, `&lt;img src=x onerror=&#34;alert(1)&#34;&gt; <mark>deploy</mark> &amp; roll back`, highlightSnippet(snippet))
+	assert.Equal(t, "no match", highlightSnippet("no match"))
+}
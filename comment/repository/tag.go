@@ -0,0 +1,81 @@
+package repository
+
+import (
+	context "context"
+	"fmt"
+	"log"
+
+	"github.com/go-pg/pg"
+	"github.com/go-pg/pg/orm"
+)
+
+// Tag is a storage object for comment_tags table.
+// It holds one tag of a comment, the org is kept along to count the tags of an org.
+type Tag struct {
+	tableName struct{} `sql:"comment_tags"`
+
+	CommentID uint64 `sql:",pk"`
+	Org       string
+	Tag       string `sql:",pk"`
+}
+
+// String ...
+func (t Tag) String() string {
+	return fmt.Sprintf("Tag<%d %s %s>", t.CommentID, t.Org, t.Tag)
+}
+
+// TagCount is the number of comments carrying a tag.
+type TagCount struct {
+	Tag   string
+	Count int
+}
+
+// TagCounts counts the active, visible comments of given org per tag, most used tags first.
+func (r *commentRepoImpl) TagCounts(ctx context.Context, org string) ([]TagCount, error) {
+	var counts []TagCount
+	_, err := r.db.Query(&counts, `
+		SELECT t.tag, count(*) AS count
+		FROM comment_tags t JOIN comments c ON c.id = t.comment_id
+		WHERE t.org = ? AND c.is_deleted = ? AND c.is_hidden = ?
+		GROUP BY t.tag
+		ORDER BY count DESC, t.tag ASC`, org, false, false)
+	if err != nil {
+		log.Printf("ERROR: failed to count tags for org %v, err: %v", org, err)
+		return nil, err
+	}
+
+	return counts, nil
+}
+
+// saveTags inserts the tags of a saved comment.
+func saveTags(db orm.DB, c *Comment) error {
+	if len(c.Tags) == 0 {
+		return nil
+	}
+
+	tags := make([]Tag, len(c.Tags))
+	for i, tag := range c.Tags {
+		tags[i] = Tag{CommentID: c.ID, Org: c.Org, Tag: tag}
+	}
+	_, err := db.Model(&tags).Insert()
+	return err
+}
+
+// loadTags sets the tags of given comments in alphabetical order.
+func (r *commentRepoImpl) loadTags(comments []Comment) error {
+	ids, index := indexComments(comments)
+	if len(ids) == 0 {
+		return nil
+	}
+
+	var tags []Tag
+	err := r.db.Model(&tags).Where("comment_id IN (?)", pg.In(ids)).Order("comment_id ASC", "tag ASC").Select()
+	if err != nil {
+		return err
+	}
+	for _, t := range tags {
+		c := &comments[index[t.CommentID]]
+		c.Tags = append(c.Tags, t.Tag)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package repository
+
+import (
+	context "context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/go-pg/pg"
+)
+
+// Vote is a storage object for comment_votes table.
+// It holds the up (+1) or down (-1) vote of a user on a comment, which is summed up in Comment.Score.
+type Vote struct {
+	tableName struct{} `sql:"comment_votes"`
+
+	CommentID uint64    `json:"comment_id" sql:",pk"`
+	Voter     string    `json:"voter" sql:",pk"`
+	Value     int       `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// String ...
+func (v Vote) String() string {
+	return fmt.Sprintf("Vote<%d %s %d %v %v>", v.CommentID, v.Voter, v.Value, v.CreatedAt, v.UpdatedAt)
+}
+
+// Vote stores, changes or, if its value is zero, withdraws the vote of a user on an active, visible comment
+// of given org and updates the score of the comment along with it.
+func (r *commentRepoImpl) Vote(ctx context.Context, org string, v *Vote) error {
+	err := r.db.RunInTransaction(func(tx *pg.Tx) error {
+		c := &Comment{}
+		err := tx.Model(c).Column("id").Where("id=? and org=? and is_deleted=? and is_hidden=?", v.CommentID, org, false, false).For("UPDATE").Select()
+		if err == pg.ErrNoRows {
+			return ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+
+		old := &Vote{CommentID: v.CommentID, Voter: v.Voter}
+		err = tx.Model(old).WherePK().Select()
+		if err != nil && err != pg.ErrNoRows {
+			return err
+		}
+		delta := v.Value - old.Value
+		if delta == 0 {
+			return nil
+		}
+
+		if v.Value == 0 {
+			_, err = tx.Model(old).WherePK().Delete()
+		} else {
+			v.CreatedAt = time.Now()
+			v.UpdatedAt = v.CreatedAt
+			_, err = tx.Model(v).
+				OnConflict("(comment_id, voter) DO UPDATE").
+				Set("value=EXCLUDED.value, updated_at=EXCLUDED.updated_at").
+				Insert()
+		}
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.Model(c).Set("score=score+?", delta).WherePK().Update()
+		return err
+	})
+
+	if err != nil && err != ErrNotFound {
+		log.Printf("ERROR: failed to store vote %+v for org %v, err: %v", v, org, err)
+	}
+	return err
+}
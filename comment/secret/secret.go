@@ -0,0 +1,95 @@
+package secret
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// kinds of detected secrets.
+const (
+	KindGithubToken  = "github_token"
+	KindAWSAccessKey = "aws_access_key"
+	KindAWSSecretKey = "aws_secret_key"
+	KindPrivateKey   = "private_key"
+	KindJWT          = "jwt"
+	KindEmail        = "email"
+)
+
+// detector finds one kind of secret.
+type detector struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+// detectors are checked in order, an earlier one wins when matches overlap.
+var detectors = []detector{
+	{KindPrivateKey, regexp.MustCompile(`(?s)-----BEGIN [A-Z ]*PRIVATE KEY-----.*?(?:-----END [A-Z ]*PRIVATE KEY-----|$)`)},
+	{KindGithubToken, regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,255}|github_pat_[A-Za-z0-9_]{22,255})\b`)},
+	{KindAWSAccessKey, regexp.MustCompile(`\b(?:AKIA|ASIA|AGPA|AIDA|AROA|ANPA|ANVA|AIPA)[A-Z0-9]{16}\b`)},
+	{KindAWSSecretKey, regexp.MustCompile(`(?i)\baws_?secret_?access_?key\b["']?\s*[:=]\s*["']?[A-Za-z0-9/+=]{40}`)},
+	{KindJWT, regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]*`)},
+	{KindEmail, regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`)},
+}
+
+// Finding is a secret detected in a text.
+type Finding struct {
+	Kind string
+	// Start and End are the byte offsets of the secret in the text.
+	Start int
+	End   int
+}
+
+// Scan returns the secrets in given text ordered by their position. Findings do not overlap.
+func Scan(text string) []Finding {
+	var findings []Finding
+	for _, d := range detectors {
+		for _, loc := range d.pattern.FindAllStringIndex(text, -1) {
+			f := Finding{Kind: d.kind, Start: loc[0], End: loc[1]}
+			if !overlaps(findings, f) {
+				findings = append(findings, f)
+			}
+		}
+	}
+
+	sort.Slice(findings, func(i, j int) bool {
+		return findings[i].Start < findings[j].Start
+	})
+	return findings
+}
+
+// overlaps tells whether f overlaps any of the findings.
+func overlaps(findings []Finding, f Finding) bool {
+	for _, other := range findings {
+		if f.Start < other.End && other.Start < f.End {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact replaces the findings returned by Scan for given text with a placeholder naming their kind.
+func Redact(text string, findings []Finding) string {
+	var b strings.Builder
+	last := 0
+	for _, f := range findings {
+		b.WriteString(text[last:f.Start])
+		b.WriteString("[REDACTED:" + f.Kind + "]")
+		last = f.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Kinds returns the distinct kinds of the findings in order of their first appearance.
+func Kinds(findings []Finding) []string {
+	var kinds []string
+	seen := map[string]bool{}
+	for _, f := range findings {
+		if !seen[f.Kind] {
+			seen[f.Kind] = true
+			kinds = append(kinds, f.Kind)
+		}
+	}
+	return kinds
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"net/url"
+	"os"
+	"strings"
 	"sync"
 
 	"github.com/google/go-github/github"
@@ -14,6 +17,9 @@ var (
 	// initOnce protects the following
 	initOnce         sync.Once
 	singletonHandler *handlerImpl
+
+	// ErrUnauthorized is returned when Github rejects the token of the caller.
+	ErrUnauthorized = errors.New("invalid Github token")
 )
 
 // User is a model for a Git user.
@@ -28,8 +34,13 @@ type User struct {
 // go:generate mockery -inpkg -case underscore -name Handler
 type Handler interface {
 	IsValidOrg(ctx context.Context, org string) (bool, error)
+	IsValidRepo(ctx context.Context, org, repo string) (bool, error)
+	IsValidIssue(ctx context.Context, org, repo string, number int) (bool, error)
 	IsMember(ctx context.Context, org, user string) (bool, error)
+	FilterMembers(ctx context.Context, org string, users []string) (map[string]bool, error)
 	ListAllMembers(ctx context.Context, org string) ([]*User, error)
+	GetAuthenticatedUser(ctx context.Context, token string) (string, error)
+	IsOrgAdmin(ctx context.Context, token, org string) (bool, error)
 }
 
 type handlerImpl struct {
@@ -37,15 +48,57 @@ type handlerImpl struct {
 }
 
 // GetHandler initializes the Github client and return the handler.
+// The client talks to $GITHUB_API_URL when set, e.g. a Github Enterprise or a fake server.
 func GetHandler() Handler {
 	initOnce.Do(func() {
-		singletonHandler = &handlerImpl{
-			client: github.NewClient(nil),
+		h, err := newHandler(os.Getenv("GITHUB_API_URL"))
+		if err != nil {
+			log.Printf("ERROR: invalid $GITHUB_API_URL, using the public Github API, err: %v", err)
+			h, _ = newHandler("")
 		}
+		singletonHandler = h
 	})
 	return singletonHandler
 }
 
+// newHandler returns a handler for the Github API v3 at given base URL, the public one if empty.
+func newHandler(baseURL string) (*handlerImpl, error) {
+	client := github.NewClient(nil)
+	if baseURL != "" {
+		u, err := url.Parse(strings.TrimSuffix(baseURL, "/") + "/")
+		if err != nil {
+			return nil, err
+		}
+		client.BaseURL = u
+	}
+	return &handlerImpl{client: client}, nil
+}
+
+// clientFor returns a client authenticated as the owner of given token.
+func (h *handlerImpl) clientFor(token string) *github.Client {
+	client := github.NewClient(&http.Client{Transport: &tokenTransport{token: token}})
+	client.BaseURL = h.client.BaseURL
+	return client
+}
+
+// tokenTransport adds the Github token to every request.
+type tokenTransport struct {
+	token string
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// requests must not be modified by a RoundTripper, so set the header on a copy.
+	r := new(http.Request)
+	*r = *req
+	r.Header = make(http.Header, len(req.Header)+1)
+	for k, v := range req.Header {
+		r.Header[k] = v
+	}
+	r.Header.Set("Authorization", "token "+t.token)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
 // IsValidOrg checks whether the organization exists in Github.
 func (h *handlerImpl) IsValidOrg(ctx context.Context, org string) (bool, error) {
 	_, resp, err := h.client.Organizations.Get(ctx, org)
@@ -59,6 +112,33 @@ func (h *handlerImpl) IsValidOrg(ctx context.Context, org string) (bool, error)
 	return resp.StatusCode == http.StatusOK, nil
 }
 
+// IsValidRepo checks whether the repository exists in specified Github org.
+func (h *handlerImpl) IsValidRepo(ctx context.Context, org, repo string) (bool, error) {
+	_, resp, err := h.client.Repositories.Get(ctx, org, repo)
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return false, nil
+		}
+		log.Printf("ERROR: failed to validate repo %v/%v from Github, err: %v", org, repo, err)
+		return false, err
+	}
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// IsValidIssue checks whether the issue, or pull request, exists in specified repository of a Github org.
+func (h *handlerImpl) IsValidIssue(ctx context.Context, org, repo string, number int) (bool, error) {
+	_, resp, err := h.client.Issues.Get(ctx, org, repo, number)
+	if err != nil {
+		// Github answers 410 for deleted issues.
+		if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone) {
+			return false, nil
+		}
+		log.Printf("ERROR: failed to validate issue %v/%v#%v from Github, err: %v", org, repo, number, err)
+		return false, err
+	}
+	return resp.StatusCode == http.StatusOK, nil
+}
+
 // IsMember checks whether the user is a public member of specified org in Github.
 func (h *handlerImpl) IsMember(ctx context.Context, org, user string) (bool, error) {
 	isMember, _, err := h.client.Organizations.IsPublicMember(ctx, org, user)
@@ -69,6 +149,39 @@ func (h *handlerImpl) IsMember(ctx context.Context, org, user string) (bool, err
 	return isMember, nil
 }
 
+// FilterMembers checks which of given users are public members of specified org in Github.
+// Public members are listed a page at a time until all users are found, so that checking many
+// users costs a few requests instead of one per user. The returned set is keyed by lowercase login.
+func (h *handlerImpl) FilterMembers(ctx context.Context, org string, users []string) (map[string]bool, error) {
+	wanted := map[string]bool{}
+	for _, user := range users {
+		wanted[strings.ToLower(user)] = true
+	}
+	members := map[string]bool{}
+	opt := &github.ListMembersOptions{
+		PublicOnly:  true,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	for len(members) < len(wanted) {
+		page, resp, err := h.client.Organizations.ListMembers(ctx, org, opt)
+		if err != nil {
+			log.Printf("ERROR: failed to fetch org %v public members from Github, err: %v", org, err)
+			return nil, err
+		}
+		for _, member := range page {
+			if login := strings.ToLower(member.GetLogin()); wanted[login] {
+				members[login] = true
+			}
+		}
+		if resp.NextPage == 0 {
+			break
+		}
+		opt.Page = resp.NextPage
+	}
+	return members, nil
+}
+
 // ListAllMembers fetch all members of specified Github org and return slice of *User.
 func (h *handlerImpl) ListAllMembers(ctx context.Context, org string) ([]*User, error) {
 	allMembers := []*github.User{}
@@ -113,3 +226,32 @@ func (h *handlerImpl) ListAllMembers(ctx context.Context, org string) ([]*User,
 	}
 	return users, nil
 }
+
+// GetAuthenticatedUser returns the login of the owner of given token.
+func (h *handlerImpl) GetAuthenticatedUser(ctx context.Context, token string) (string, error) {
+	user, resp, err := h.clientFor(token).Users.Get(ctx, "")
+	if err != nil {
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return "", ErrUnauthorized
+		}
+		log.Printf("ERROR: failed to fetch authenticated user from Github, err: %v", err)
+		return "", err
+	}
+	return user.GetLogin(), nil
+}
+
+// IsOrgAdmin checks whether the owner of given token is an active admin (owner) of specified org in Github.
+func (h *handlerImpl) IsOrgAdmin(ctx context.Context, token, org string) (bool, error) {
+	membership, resp, err := h.clientFor(token).Organizations.GetOrgMembership(ctx, "", org)
+	if err != nil {
+		if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusForbidden) {
+			return false, nil
+		}
+		if resp != nil && resp.StatusCode == http.StatusUnauthorized {
+			return false, ErrUnauthorized
+		}
+		log.Printf("ERROR: failed to fetch membership of org %v from Github, err: %v", org, err)
+		return false, err
+	}
+	return membership.GetState() == "active" && membership.GetRole() == "admin", nil
+}
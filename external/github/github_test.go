@@ -0,0 +1,135 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/rahulbharuka/github-proxy/external/github/githubtest"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestHandler(t *testing.T) (*handlerImpl, *githubtest.Server) {
+	server := githubtest.NewServer()
+	h, err := newHandler(server.URL)
+	assert.Nil(t, err)
+	return h, server
+}
+
+func TestGetHandler(t *testing.T) {
+	h1 := GetHandler()
+	h2 := GetHandler()
+	assert.Equal(t, h1, h2)
+}
+
+func TestIsValidOrg(t *testing.T) {
+	h, server := newTestHandler(t)
+	defer server.Close()
+	server.AddOrg("github")
+
+	isValid, err := h.IsValidOrg(context.Background(), "github")
+	assert.Nil(t, err)
+	assert.True(t, isValid)
+
+	isValid, err = h.IsValidOrg(context.Background(), "unknown")
+	assert.Nil(t, err)
+	assert.False(t, isValid)
+}
+
+func TestIsValidRepo(t *testing.T) {
+	h, server := newTestHandler(t)
+	defer server.Close()
+	server.AddRepo("github", "hub")
+
+	isValid, err := h.IsValidRepo(context.Background(), "github", "Hub")
+	assert.Nil(t, err)
+	assert.True(t, isValid)
+
+	isValid, err = h.IsValidRepo(context.Background(), "github", "other")
+	assert.Nil(t, err)
+	assert.False(t, isValid)
+}
+
+func TestIsValidIssue(t *testing.T) {
+	h, server := newTestHandler(t)
+	defer server.Close()
+	server.AddRepo("github", "hub", 42)
+
+	isValid, err := h.IsValidIssue(context.Background(), "github", "hub", 42)
+	assert.Nil(t, err)
+	assert.True(t, isValid)
+
+	isValid, err = h.IsValidIssue(context.Background(), "github", "hub", 7)
+	assert.Nil(t, err)
+	assert.False(t, isValid)
+}
+
+func TestIsMember(t *testing.T) {
+	h, server := newTestHandler(t)
+	defer server.Close()
+	server.AddOrg("github", "awesome-user")
+
+	isMember, err := h.IsMember(context.Background(), "github", "awesome-user")
+	assert.Nil(t, err)
+	assert.True(t, isMember)
+
+	isMember, err = h.IsMember(context.Background(), "github", "other-user")
+	assert.Nil(t, err)
+	assert.False(t, isMember)
+}
+
+func TestFilterMembers(t *testing.T) {
+	h, server := newTestHandler(t)
+	defer server.Close()
+	members := []string{}
+	for i := 0; i < 250; i++ {
+		members = append(members, fmt.Sprintf("user%03d", i))
+	}
+	server.AddOrg("github", members...)
+
+	found, err := h.FilterMembers(context.Background(), "github", []string{"User007", "user249", "other-user"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]bool{"user007": true, "user249": true}, found)
+
+	found, err = h.FilterMembers(context.Background(), "github", nil)
+	assert.Nil(t, err)
+	assert.Empty(t, found)
+}
+
+func TestGetAuthenticatedUser(t *testing.T) {
+	h, server := newTestHandler(t)
+	defer server.Close()
+	server.AddToken("t0ken", "awesome-user")
+
+	login, err := h.GetAuthenticatedUser(context.Background(), "t0ken")
+	assert.Nil(t, err)
+	assert.Equal(t, "awesome-user", login)
+
+	_, err = h.GetAuthenticatedUser(context.Background(), "stolen")
+	assert.Equal(t, ErrUnauthorized, err)
+}
+
+func TestIsOrgAdmin(t *testing.T) {
+	h, server := newTestHandler(t)
+	defer server.Close()
+	server.AddOrg("github", "awesome-user", "other-user")
+	server.SetRole("github", "awesome-user", "admin")
+	server.AddToken("admin-t0ken", "awesome-user")
+	server.AddToken("member-t0ken", "other-user")
+	server.AddToken("outsider-t0ken", "outsider")
+
+	isAdmin, err := h.IsOrgAdmin(context.Background(), "admin-t0ken", "github")
+	assert.Nil(t, err)
+	assert.True(t, isAdmin)
+
+	isAdmin, err = h.IsOrgAdmin(context.Background(), "member-t0ken", "github")
+	assert.Nil(t, err)
+	assert.False(t, isAdmin)
+
+	isAdmin, err = h.IsOrgAdmin(context.Background(), "outsider-t0ken", "github")
+	assert.Nil(t, err)
+	assert.False(t, isAdmin)
+
+	_, err = h.IsOrgAdmin(context.Background(), "stolen", "github")
+	assert.Equal(t, ErrUnauthorized, err)
+}
@@ -0,0 +1,237 @@
+// Package githubtest provides a fake Github API v3 server, so that code talking to Github can be tested offline.
+package githubtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Server is a fake Github API v3 server serving the orgs, users and tokens registered on it.
+type Server struct {
+	*httptest.Server
+
+	mu     sync.Mutex
+	orgs   map[string]*org
+	tokens map[string]string
+	// repos maps "org/repo" to the numbers of the issues of the repo.
+	repos map[string]map[int]bool
+}
+
+// org is a Github org known to the fake server.
+type org struct {
+	publicMembers map[string]bool
+	// roles maps the logins of all members, public or private, to their role.
+	roles map[string]string
+}
+
+// NewServer starts and returns a new fake Github server. The caller should call Close when finished.
+func NewServer() *Server {
+	s := &Server{
+		orgs:   map[string]*org{},
+		tokens: map[string]string{},
+		repos:  map[string]map[int]bool{},
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	return s
+}
+
+// AddOrg registers an org along with its public members.
+func (s *Server) AddOrg(name string, publicMembers ...string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o := &org{publicMembers: map[string]bool{}, roles: map[string]string{}}
+	for _, m := range publicMembers {
+		o.publicMembers[strings.ToLower(m)] = true
+		o.roles[strings.ToLower(m)] = "member"
+	}
+	s.orgs[strings.ToLower(name)] = o
+}
+
+// AddRepo registers a repository of an org along with the numbers of its issues.
+func (s *Server) AddRepo(org, name string, issues ...int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	numbers := map[int]bool{}
+	for _, n := range issues {
+		numbers[n] = true
+	}
+	s.repos[strings.ToLower(org+"/"+name)] = numbers
+}
+
+// SetRole sets the role, "admin" or "member", of a user in a registered org.
+func (s *Server) SetRole(name, login, role string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.orgs[strings.ToLower(name)].roles[strings.ToLower(login)] = role
+}
+
+// AddToken registers a token authenticating given user.
+func (s *Server) AddToken(token, login string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.tokens[token] = login
+}
+
+// serveHTTP routes the request by its path segments.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 1 && parts[0] == "user":
+		s.serveAuthenticatedUser(w, r)
+	case len(parts) == 2 && parts[0] == "orgs":
+		s.serveOrg(w, parts[1])
+	case len(parts) == 3 && parts[0] == "orgs" && parts[2] == "public_members":
+		s.servePublicMembers(w, r, parts[1])
+	case len(parts) == 4 && parts[0] == "orgs" && parts[2] == "public_members":
+		s.servePublicMember(w, parts[1], parts[3])
+	case len(parts) == 3 && parts[0] == "repos":
+		s.serveRepo(w, parts[1], parts[2])
+	case len(parts) == 5 && parts[0] == "repos" && parts[3] == "issues":
+		s.serveIssue(w, parts[1], parts[2], parts[4])
+	case len(parts) == 4 && parts[0] == "user" && parts[1] == "memberships" && parts[2] == "orgs":
+		s.serveMembership(w, r, parts[3])
+	default:
+		writeError(w, http.StatusNotFound, "Not Found")
+	}
+}
+
+func (s *Server) serveAuthenticatedUser(w http.ResponseWriter, r *http.Request) {
+	login, ok := s.login(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Bad credentials")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"login": login})
+}
+
+func (s *Server) serveOrg(w http.ResponseWriter, name string) {
+	if _, ok := s.orgs[strings.ToLower(name)]; !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"login": name})
+}
+
+// servePublicMembers lists the public members of an org sorted by login, paginated like Github.
+func (s *Server) servePublicMembers(w http.ResponseWriter, r *http.Request, name string) {
+	o, ok := s.orgs[strings.ToLower(name)]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	logins := []string{}
+	for login := range o.publicMembers {
+		logins = append(logins, login)
+	}
+	sort.Strings(logins)
+
+	page, perPage := intParam(r, "page", 1), intParam(r, "per_page", 30)
+	start := (page - 1) * perPage
+	if start > len(logins) {
+		start = len(logins)
+	}
+	end := start + perPage
+	if end >= len(logins) {
+		end = len(logins)
+	} else {
+		next := *r.URL
+		q := next.Query()
+		q.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = q.Encode()
+		w.Header().Set("Link", `<`+s.URL+next.RequestURI()+`>; rel="next"`)
+	}
+
+	users := []map[string]interface{}{}
+	for _, login := range logins[start:end] {
+		users = append(users, map[string]interface{}{"login": login})
+	}
+	writeJSON(w, http.StatusOK, users)
+}
+
+func (s *Server) servePublicMember(w http.ResponseWriter, name, user string) {
+	o, ok := s.orgs[strings.ToLower(name)]
+	if !ok || !o.publicMembers[strings.ToLower(user)] {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) serveRepo(w http.ResponseWriter, owner, name string) {
+	if _, ok := s.repos[strings.ToLower(owner+"/"+name)]; !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"name": name, "full_name": owner + "/" + name})
+}
+
+func (s *Server) serveIssue(w http.ResponseWriter, owner, name, number string) {
+	n, err := strconv.Atoi(number)
+	if err != nil || !s.repos[strings.ToLower(owner+"/"+name)][n] {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"number": n})
+}
+
+func (s *Server) serveMembership(w http.ResponseWriter, r *http.Request, name string) {
+	login, ok := s.login(r)
+	if !ok {
+		writeError(w, http.StatusUnauthorized, "Bad credentials")
+		return
+	}
+	o, ok := s.orgs[strings.ToLower(name)]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	role, ok := o.roles[strings.ToLower(login)]
+	if !ok {
+		writeError(w, http.StatusNotFound, "Not Found")
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"state": "active", "role": role})
+}
+
+// login returns the user authenticated by the Authorization header of the request.
+func (s *Server) login(r *http.Request) (string, bool) {
+	auth := r.Header.Get("Authorization")
+	for _, scheme := range []string{"token ", "Bearer "} {
+		if strings.HasPrefix(auth, scheme) {
+			login, ok := s.tokens[strings.TrimPrefix(auth, scheme)]
+			return login, ok
+		}
+	}
+	return "", false
+}
+
+// intParam returns the positive integer query parameter of the request, or def if missing or invalid.
+func intParam(r *http.Request, key string, def int) int {
+	v, err := strconv.Atoi(r.URL.Query().Get(key))
+	if err != nil || v <= 0 {
+		return def
+	}
+	return v
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]interface{}{"message": message})
+}
@@ -13,6 +13,50 @@ type MockHandler struct {
 	mock.Mock
 }
 
+// FilterMembers provides a mock function with given fields: ctx, org, users
+func (_m *MockHandler) FilterMembers(ctx context.Context, org string, users []string) (map[string]bool, error) {
+	ret := _m.Called(ctx, org, users)
+
+	var r0 map[string]bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, []string) map[string]bool); ok {
+		r0 = rf(ctx, org, users)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]bool)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, []string) error); ok {
+		r1 = rf(ctx, org, users)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetAuthenticatedUser provides a mock function with given fields: ctx, token
+func (_m *MockHandler) GetAuthenticatedUser(ctx context.Context, token string) (string, error) {
+	ret := _m.Called(ctx, token)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string) string); ok {
+		r0 = rf(ctx, token)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, token)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // IsMember provides a mock function with given fields: ctx, org, user
 func (_m *MockHandler) IsMember(ctx context.Context, org string, user string) (bool, error) {
 	ret := _m.Called(ctx, org, user)
@@ -34,6 +78,48 @@ func (_m *MockHandler) IsMember(ctx context.Context, org string, user string) (b
 	return r0, r1
 }
 
+// IsOrgAdmin provides a mock function with given fields: ctx, token, org
+func (_m *MockHandler) IsOrgAdmin(ctx context.Context, token string, org string) (bool, error) {
+	ret := _m.Called(ctx, token, org)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = rf(ctx, token, org)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, token, org)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsValidIssue provides a mock function with given fields: ctx, org, repo, number
+func (_m *MockHandler) IsValidIssue(ctx context.Context, org string, repo string, number int) (bool, error) {
+	ret := _m.Called(ctx, org, repo, number)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, int) bool); ok {
+		r0 = rf(ctx, org, repo, number)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, int) error); ok {
+		r1 = rf(ctx, org, repo, number)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // IsValidOrg provides a mock function with given fields: ctx, org
 func (_m *MockHandler) IsValidOrg(ctx context.Context, org string) (bool, error) {
 	ret := _m.Called(ctx, org)
@@ -55,6 +141,27 @@ func (_m *MockHandler) IsValidOrg(ctx context.Context, org string) (bool, error)
 	return r0, r1
 }
 
+// IsValidRepo provides a mock function with given fields: ctx, org, repo
+func (_m *MockHandler) IsValidRepo(ctx context.Context, org string, repo string) (bool, error) {
+	ret := _m.Called(ctx, org, repo)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) bool); ok {
+		r0 = rf(ctx, org, repo)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, org, repo)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ListAllMembers provides a mock function with given fields: ctx, org
 func (_m *MockHandler) ListAllMembers(ctx context.Context, org string) ([]*User, error) {
 	ret := _m.Called(ctx, org)
@@ -3,7 +3,7 @@ module github.com/rahulbharuka/github-proxy
 go 1.12
 
 require (
-	github.com/gin-gonic/gin v1.6.3
+	github.com/gin-gonic/gin v1.7.7
 	github.com/go-pg/pg v8.0.6+incompatible
 	github.com/google/go-github v17.0.0+incompatible
 	github.com/google/go-querystring v1.0.0 // indirect